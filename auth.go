@@ -2,13 +2,12 @@ package buildingx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"os"
-	"time"
 )
 
 type AuthRequest struct {
@@ -24,65 +23,103 @@ type SBToken struct {
 	TokenType   string `json:"token_type"`
 }
 
+// GetToken exchanges the API credentials for an access token and returns the token
 func GetToken() (string, error) {
+	return GetTokenWithContext(context.Background())
+}
+
+// GetTokenWithContext is like GetToken but the credential exchange is bound to the given context
+func GetTokenWithContext(ctx context.Context) (string, error) {
 
-	// verify that you have the environment variables needed
-	clientID := os.Getenv("BUILDINGX_CLIENT_ID")
-	if clientID == "" {
-		return "", errors.New("missing client id")
+	tkn, err := envClient().requestToken(ctx)
+	if err != nil {
+		return "", err
 	}
-	clientSecret := os.Getenv("BUILDINGX_CLIENT_SECRET")
-	if clientSecret == "" {
-		return "", errors.New("missing client secret")
+	return tkn.AccessToken, nil
+
+}
+
+// requestToken performs the credential exchange and returns the full token response, including its lifetime
+func (c *Client) requestToken(ctx context.Context) (SBToken, error) {
+
+	tkn := SBToken{}
+	err := c.operation(ctx, "GetToken", nil, func(ctx context.Context) error {
+		ctx, done := c.startCall(ctx, CallEvent{Method: "POST", Path: c.config.AuthURL})
+		var err error
+		var status int
+		tkn, status, err = c.exchangeCredentials(ctx)
+		done(status, err)
+		return err
+	})
+	return tkn, err
+
+}
+
+func (c *Client) exchangeCredentials(ctx context.Context) (SBToken, int, error) {
+
+	// verify that you have the configuration needed
+	if c.config.ClientID == "" {
+		return SBToken{}, 0, &ConfigError{Setting: "client id"}
 	}
-	audience := os.Getenv("BUILDINGX_AUDIENCE")
-	if audience == "" {
-		return "", errors.New("missing audience")
+	if c.config.ClientSecret == "" {
+		return SBToken{}, 0, &ConfigError{Setting: "client secret"}
 	}
-	authURL := os.Getenv("BUILDINGX_AUTH_URL")
-	if authURL == "" {
-		return "", errors.New("missing authorization URL")
+	if c.config.Audience == "" {
+		return SBToken{}, 0, &ConfigError{Setting: "audience"}
+	}
+	if c.config.AuthURL == "" {
+		return SBToken{}, 0, &ConfigError{Setting: "authorization URL"}
 	}
 
 	authRequest := AuthRequest{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		Audience:     audience,
+		ClientID:     c.config.ClientID,
+		ClientSecret: c.config.ClientSecret,
+		Audience:     c.config.Audience,
 		GrantType:    "client_credentials",
-		URL:          authURL,
+		URL:          c.config.AuthURL,
 	}
 	authRequestBytes, _ := json.Marshal(authRequest)
 	authRequestReader := bytes.NewReader(authRequestBytes)
 
-	client := &http.Client{Timeout: time.Duration(20) * time.Second}
-	req, err := http.NewRequest("POST", authRequest.URL, authRequestReader)
+	req, err := http.NewRequestWithContext(ctx, "POST", authRequest.URL, authRequestReader)
 	if err != nil {
-		return "", err
+		return SBToken{}, 0, err
 
 	}
 	req.Header.Add("accept", "application/json")
 	req.Header.Add("content-type", "application/json")
 
-	resp, err := client.Do(req)
+	resp, err := c.httpClientFor(ctx).Do(req)
 	if err != nil {
-		return "", fmt.Errorf("unexpected error while invoking http client: %s", err.Error())
+		return SBToken{}, 0, fmt.Errorf("unexpected error while invoking http client: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Path:       authRequest.URL,
+		}
+
+		// the OAuth provider reports its errors with detail or error_description properties
 		var data map[string]interface{}
 		decoder := json.NewDecoder(resp.Body)
-		err := decoder.Decode(&data)
-		if err == nil {
-			return "", errors.New("error message from BuildingX: " + data["detail"].(string))
+		if err := decoder.Decode(&data); err == nil {
+			for _, key := range []string{"detail", "error_description", "error"} {
+				if detail, ok := data[key].(string); ok && detail != "" {
+					apiErr.Errors = append(apiErr.Errors, SBErrorResponse{Status: fmt.Sprint(resp.StatusCode), Detail: detail})
+					break
+				}
+			}
 		}
-		return "", errors.New("got non-200 response from BuildingX API with no additional information")
+		return SBToken{}, resp.StatusCode, apiErr
 
 	}
 	tkn := SBToken{}
 	body, _ := ioutil.ReadAll(resp.Body)
 	if err := json.Unmarshal(body, &tkn); err != nil {
-		return "", errors.New("Error parsing API response. String submitted: " + string(body))
+		return SBToken{}, resp.StatusCode, errors.New("Error parsing API response. String submitted: " + string(body))
 	}
-	return string(tkn.AccessToken), nil
+	return tkn, resp.StatusCode, nil
 
 }
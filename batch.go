@@ -0,0 +1,268 @@
+package buildingx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const defaultBatchConcurrency = 4
+
+// PointCommand is a command of a batch: the value to write to a point and the options of the command
+type PointCommand struct {
+	Point   *Point
+	Value   string
+	Options []CommandOption
+}
+
+// CommandOutcome tells what happened to a command of a batch
+type CommandOutcome int
+
+const (
+	// CommandSucceeded means the command was accepted by the API
+	CommandSucceeded CommandOutcome = iota + 1
+	// CommandInvalid means the command was not sent because the point is not writable, the priority is invalid
+	// or the value failed validation
+	CommandInvalid
+	// CommandFailed means the API call failed
+	CommandFailed
+	// CommandSkipped means the command was not attempted because the batch stopped on an earlier failure or the
+	// context was cancelled
+	CommandSkipped
+	// CommandDryRun means the command was not sent because dry-run mode is on. Err is the DryRunError holding the
+	// request that would have been sent.
+	CommandDryRun
+)
+
+func (o CommandOutcome) String() string {
+
+	switch o {
+	case CommandSucceeded:
+		return "succeeded"
+	case CommandInvalid:
+		return "invalid"
+	case CommandFailed:
+		return "failed"
+	case CommandSkipped:
+		return "skipped"
+	case CommandDryRun:
+		return "dry run"
+	}
+	return "unknown"
+
+}
+
+// CommandResult is the result of a command of a batch
+type CommandResult struct {
+	Point   *Point
+	Value   string
+	Outcome CommandOutcome
+	// Err is the validation or API error when the command is invalid or failed, or the DryRunError in dry-run mode
+	Err error
+	// RolledBack is set when the command succeeded and was undone because another command of the batch failed
+	RolledBack bool
+	// RollbackErr is the error of the attempt to undo the command, in which case the point keeps the value
+	RollbackErr error
+}
+
+// BatchError is returned by CommandPoints when at least one command is invalid or failed
+type BatchError struct {
+	// Failed is the number of commands that are invalid or failed
+	Failed int
+	// Total is the number of commands in the batch
+	Total int
+	// Err is the error of the first command that is invalid or failed
+	Err error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d of %d point commands failed: %v", e.Failed, e.Total, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// BatchOption changes how CommandPoints runs a batch
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	concurrency int
+	stop        bool
+	rollback    bool
+}
+
+// BatchConcurrency sets how many commands of a batch are sent at the same time. It defaults to 4.
+func BatchConcurrency(n int) BatchOption {
+	return func(o *batchOptions) { o.concurrency = n }
+}
+
+// StopOnError stops a batch at the first command that is invalid or fails. Every command is validated before
+// any is sent, so an invalid value stops the batch before anything is written. Commands not yet started are
+// skipped; those already in flight complete.
+func StopOnError() BatchOption {
+	return func(o *batchOptions) { o.stop = true }
+}
+
+// RollbackOnError stops a batch like StopOnError and then undoes the commands that succeeded. The value of each
+// point is read before it is commanded, and it is restored (or the priority is released when its slot was
+// empty) during the rollback.
+func RollbackOnError() BatchOption {
+	return func(o *batchOptions) { o.stop, o.rollback = true, true }
+}
+
+// CommandPoints commands many points with bounded concurrency and returns the result of each command, in the
+// order of commands. The error is a BatchError when any command is invalid or failed.
+func CommandPoints(session *Session, commands []PointCommand, opts ...BatchOption) ([]CommandResult, error) {
+	return CommandPointsWithContext(context.Background(), session, commands, opts...)
+}
+
+// CommandPointsWithContext is like CommandPoints but uses the given context for the API calls and any token refresh
+func CommandPointsWithContext(ctx context.Context, session *Session, commands []PointCommand, opts ...BatchOption) ([]CommandResult, error) {
+	return session.apiClient().commandPoints(ctx, session, commands, opts...)
+}
+
+// CommandPoints commands many points with bounded concurrency and returns the result of each command
+func (c *Client) CommandPoints(commands []PointCommand, opts ...BatchOption) ([]CommandResult, error) {
+	return c.CommandPointsWithContext(context.Background(), commands, opts...)
+}
+
+// CommandPointsWithContext is like CommandPoints but uses the given context for the API calls and any token refresh
+func (c *Client) CommandPointsWithContext(ctx context.Context, commands []PointCommand, opts ...BatchOption) ([]CommandResult, error) {
+	return c.commandPoints(ctx, c.session, commands, opts...)
+}
+
+func (c *Client) commandPoints(ctx context.Context, session *Session, commands []PointCommand, opts ...BatchOption) ([]CommandResult, error) {
+
+	options := batchOptions{concurrency: defaultBatchConcurrency}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.concurrency < 1 {
+		options.concurrency = 1
+	}
+
+	results := make([]CommandResult, len(commands))
+	for i, command := range commands {
+		results[i] = CommandResult{Point: command.Point, Value: command.Value, Outcome: CommandSkipped}
+	}
+
+	// when stopping on errors, validate everything before writing anything
+	if options.stop {
+		invalid := false
+		for i, command := range commands {
+			if err := newCommandOptions(command.Options).check(command.Point, command.Value); err != nil {
+				results[i].Outcome, results[i].Err = CommandInvalid, err
+				invalid = true
+			}
+		}
+		if invalid {
+			return results, batchError(results)
+		}
+	}
+
+	// stopping cancels batchCtx, which only keeps commands from starting; those in flight use ctx and complete
+	batchCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	priors := make([]priorCommand, len(commands))
+
+	forEachIndex(len(commands), options.concurrency, func(i int) {
+
+		if batchCtx.Err() != nil {
+			return
+		}
+		command := commands[i]
+
+		if options.rollback {
+			priority := newCommandOptions(command.Options).priority
+			prior, err := c.priorState(ctx, session, command.Point.ID, priority)
+			if err != nil {
+				results[i].Outcome, results[i].Err = CommandFailed, err
+				stop()
+				return
+			}
+			priors[i] = prior
+		}
+
+		err := c.commandPointValue(ctx, session, command.Point, command.Value, command.Options...)
+		switch {
+		case err == nil:
+			results[i].Outcome = CommandSucceeded
+			if options.rollback && priors[i].priority == 0 {
+				c.findCommandPriority(ctx, session, command.Point.ID, &priors[i])
+			}
+			return
+		case errors.Is(err, ErrDryRun):
+			results[i].Outcome, results[i].Err = CommandDryRun, err
+			return
+		case errors.Is(err, ErrInvalidValue) || errors.Is(err, ErrPointNotWritable) || errors.Is(err, ErrInvalidPriority):
+			results[i].Outcome, results[i].Err = CommandInvalid, err
+		default:
+			results[i].Outcome, results[i].Err = CommandFailed, err
+		}
+		if options.stop {
+			stop()
+		}
+
+	})
+
+	err := batchError(results)
+	if err == nil {
+		// the commands not started when the caller cancelled are skipped
+		return results, ctx.Err()
+	}
+	if !options.rollback {
+		return results, err
+	}
+
+	// undo the commands that succeeded
+	forEachIndex(len(commands), options.concurrency, func(i int) {
+		if results[i].Outcome != CommandSucceeded {
+			return
+		}
+		point := &Point{ID: commands[i].Point.ID, DataType: commands[i].Point.DataType, Writable: true}
+		prior := priors[i]
+		results[i].RollbackErr = c.restoreCommand(ctx, session, point, prior.priority, prior.value, prior.release)
+		results[i].RolledBack = results[i].RollbackErr == nil
+	})
+
+	return results, err
+
+}
+
+// forEachIndex calls fn for the indexes 0 to n-1, running up to concurrency calls at the same time
+func forEachIndex(n, concurrency int, fn func(i int)) {
+
+	wg := sync.WaitGroup{}
+	slots := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() { <-slots; wg.Done() }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+
+}
+
+// batchError summarizes the commands that are invalid or failed, or returns nil when there are none
+func batchError(results []CommandResult) error {
+
+	batchErr := &BatchError{Total: len(results)}
+	for _, result := range results {
+		if result.Outcome == CommandInvalid || result.Outcome == CommandFailed {
+			if batchErr.Failed == 0 {
+				batchErr.Err = result.Err
+			}
+			batchErr.Failed++
+		}
+	}
+	if batchErr.Failed == 0 {
+		return nil
+	}
+	return batchErr
+
+}
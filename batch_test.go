@@ -0,0 +1,175 @@
+package buildingx_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+	"github.com/cloudlinesolutions/buildingx-operations-api/buildingxtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newBuilding returns a fake with ten numeric setpoints at 72 (vav-0 to vav-9) and a setpoint named broken
+// whose commands fail with a 500. The returned functions list the commands received as "point=value" (or
+// "point released") and report the highest number of commands in flight.
+func newBuilding(t *testing.T) (*buildingxtest.Server, *buildingx.Client, func() []string, func() int) {
+
+	fixtures := buildingxtest.DefaultFixtures()
+	for i := 0; i <= 10; i++ {
+		id := fmt.Sprintf("vav-%d", i)
+		if i == 10 {
+			id = "broken"
+		}
+		fixtures.Points = append(fixtures.Points, buildingxtest.Point{
+			Point:    buildingx.Point{ID: id, Name: id, DataType: buildingx.DataTypeNumber, Writable: true, StringValue: "72"},
+			DeviceID: "device-1",
+		})
+	}
+
+	mu := sync.Mutex{}
+	inFlight, maxInFlight := 0, 0
+	counter := func(next http.RoundTripper) http.RoundTripper {
+		return buildingx.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != "PATCH" {
+				return next.RoundTrip(req)
+			}
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}()
+			return next.RoundTrip(req)
+		})
+	}
+
+	server, client := newFake(t, fixtures, buildingx.WithMiddleware(counter))
+	server.InjectFault(buildingxtest.Fault{Method: "PATCH", Path: "points/broken", Status: 500})
+	server.InjectFault(buildingxtest.Fault{Method: "PATCH", Latency: 5 * time.Millisecond})
+
+	commandsMade := func() []string {
+		commands := make([]string, 0)
+		for _, command := range server.Commands() {
+			if command.Release {
+				commands = append(commands, command.PointID+" released")
+			} else {
+				commands = append(commands, command.PointID+"="+command.Value)
+			}
+		}
+		return commands
+	}
+	concurrency := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return maxInFlight
+	}
+	return server, client, commandsMade, concurrency
+
+}
+
+func setpointCommand(id, value string) buildingx.PointCommand {
+	return buildingx.PointCommand{Point: &buildingx.Point{ID: id, DataType: "number", Writable: true}, Value: value}
+}
+
+func TestCommandPoints(t *testing.T) {
+
+	t.Run("bounded-concurrency", func(t *testing.T) {
+		_, client, commands, concurrency := newBuilding(t)
+		batch := make([]buildingx.PointCommand, 10)
+		for i := range batch {
+			batch[i] = setpointCommand(fmt.Sprintf("vav-%d", i), "68")
+		}
+		results, err := client.CommandPoints(batch, buildingx.BatchConcurrency(3))
+		assert.Nil(t, err)
+		for i, result := range results {
+			assert.Equal(t, buildingx.CommandSucceeded, result.Outcome)
+			assert.Equal(t, batch[i].Point, result.Point)
+		}
+		assert.Equal(t, 10, len(commands()))
+		assert.True(t, concurrency() > 1 && concurrency() <= 3)
+	})
+	t.Run("per-point-results", func(t *testing.T) {
+		_, client, commands, _ := newBuilding(t)
+		results, err := client.CommandPoints([]buildingx.PointCommand{
+			setpointCommand("vav-1", "68"),
+			setpointCommand("broken", "68"),
+			setpointCommand("vav-2", "warm"),
+			setpointCommand("vav-3", "69"),
+		})
+		outcomes := make([]buildingx.CommandOutcome, len(results))
+		for i, result := range results {
+			outcomes[i] = result.Outcome
+		}
+		assert.Equal(t, []buildingx.CommandOutcome{buildingx.CommandSucceeded, buildingx.CommandFailed, buildingx.CommandInvalid, buildingx.CommandSucceeded}, outcomes)
+		assert.True(t, errors.Is(results[2].Err, buildingx.ErrInvalidValue))
+		var apiErr *buildingx.APIError
+		assert.True(t, errors.As(results[1].Err, &apiErr))
+
+		var batchErr *buildingx.BatchError
+		if !errors.As(err, &batchErr) {
+			t.Fatal("expected a BatchError but got: ", err)
+		}
+		assert.Equal(t, 2, batchErr.Failed)
+		assert.Equal(t, 4, batchErr.Total)
+		assert.ElementsMatch(t, []string{"vav-1=68", "vav-3=69"}, commands())
+	})
+	t.Run("invalid-value-stops-before-writing", func(t *testing.T) {
+		_, client, commands, _ := newBuilding(t)
+		results, err := client.CommandPoints([]buildingx.PointCommand{
+			setpointCommand("vav-1", "68"),
+			setpointCommand("vav-2", "warm"),
+		}, buildingx.StopOnError())
+		assert.True(t, errors.Is(err, buildingx.ErrInvalidValue))
+		assert.Equal(t, buildingx.CommandSkipped, results[0].Outcome)
+		assert.Equal(t, buildingx.CommandInvalid, results[1].Outcome)
+		assert.Equal(t, 0, len(commands()))
+	})
+	t.Run("stop-on-error", func(t *testing.T) {
+		_, client, commands, _ := newBuilding(t)
+		results, err := client.CommandPoints([]buildingx.PointCommand{
+			setpointCommand("vav-1", "68"),
+			setpointCommand("broken", "68"),
+			setpointCommand("vav-2", "68"),
+		}, buildingx.StopOnError(), buildingx.BatchConcurrency(1))
+		assert.NotNil(t, err)
+		assert.Equal(t, buildingx.CommandSucceeded, results[0].Outcome)
+		assert.Equal(t, buildingx.CommandFailed, results[1].Outcome)
+		assert.Equal(t, buildingx.CommandSkipped, results[2].Outcome)
+		assert.Equal(t, []string{"vav-1=68"}, commands())
+	})
+	t.Run("rollback-on-error", func(t *testing.T) {
+		server, client, commands, _ := newBuilding(t)
+		if err := client.CommandPointValue(&buildingx.Point{ID: "vav-2", Writable: true}, "70"); err != nil {
+			t.Fatal("error commanding point: ", err.Error())
+		}
+		results, err := client.CommandPoints([]buildingx.PointCommand{
+			setpointCommand("vav-1", "68"),
+			setpointCommand("vav-2", "68"),
+			setpointCommand("broken", "68"),
+		}, buildingx.RollbackOnError(), buildingx.BatchConcurrency(1))
+		assert.NotNil(t, err)
+		assert.True(t, results[0].RolledBack)
+		assert.True(t, results[1].RolledBack)
+		assert.Nil(t, results[1].RollbackErr)
+		assert.False(t, results[2].RolledBack)
+		assert.Equal(t, []string{"vav-2=70", "vav-1=68", "vav-2=68"}, commands()[:3])
+		assert.ElementsMatch(t, []string{"vav-1 released", "vav-2=70"}, commands()[3:])
+
+		// the slot that was empty is released, so vav-1 is back at its relinquish default
+		vav1, _ := server.Point("vav-1")
+		assert.Equal(t, "72", vav1.StringValue)
+		assert.Nil(t, vav1.PriorityArray)
+		vav2, _ := server.Point("vav-2")
+		assert.Equal(t, []buildingx.PriorityValue{{Priority: 16, Value: "70"}}, vav2.PriorityArray)
+	})
+
+}
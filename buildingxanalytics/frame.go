@@ -0,0 +1,324 @@
+package buildingxanalytics
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+)
+
+const defaultFetchConcurrency = 4
+
+// Method is how the value of a point is computed at a time of the frame that is not one of its samples
+type Method int
+
+const (
+	// SampleAndHold uses the last sample at or before the time
+	SampleAndHold Method = iota
+	// Interpolate draws a line between the samples around the time. Boolean and enumerated points are always
+	// sampled and held, since a value between two states has no meaning.
+	Interpolate
+)
+
+// HistoryReader reads the history of a point. *buildingx.Client implements it, and SessionReader adapts a
+// session for the package-level functions.
+type HistoryReader interface {
+	GetPointHistoryRecordsWithContext(ctx context.Context, point *buildingx.Point, start, end time.Time, opts ...buildingx.HistoryOption) ([]buildingx.HistoryRecord, error)
+}
+
+// SessionReader returns a HistoryReader that reads through the package-level functions with the session
+func SessionReader(session *buildingx.Session) HistoryReader {
+	return sessionReader{session: session}
+}
+
+type sessionReader struct {
+	session *buildingx.Session
+}
+
+func (r sessionReader) GetPointHistoryRecordsWithContext(ctx context.Context, point *buildingx.Point, start, end time.Time, opts ...buildingx.HistoryOption) ([]buildingx.HistoryRecord, error) {
+	return buildingx.GetPointHistoryRecordsWithContext(ctx, r.session, point, start, end, opts...)
+}
+
+// Frame is the history of several points aligned on a common time axis: a table with a row per time and a
+// column per point
+type Frame struct {
+	Times   []time.Time
+	Columns []Column
+	// Zone is the time zone the times are written in. It is UTC unless the frame is on a grid.
+	Zone *time.Location
+}
+
+// Column is the aligned history of a point
+type Column struct {
+	PointID string
+	Name    string
+	Units   string
+	// Values holds a value for each time of the frame. It is only meaningful when Valid is set.
+	Values []float64
+	// Valid is false at the times the point has no value, which are before its first sample (and after its
+	// last one when interpolating)
+	Valid []bool
+}
+
+// FrameOption changes how a frame is built
+type FrameOption func(*frameOptions)
+
+type frameOptions struct {
+	method      Method
+	grid        *Interval
+	zone        *time.Location
+	concurrency int
+	history     []buildingx.HistoryOption
+}
+
+// AlignMethod sets how values are computed between samples. It defaults to SampleAndHold.
+func AlignMethod(method Method) FrameOption {
+	return func(o *frameOptions) { o.method = method }
+}
+
+// OnGrid puts the rows of the frame at the start of every interval from start to end, aligned on the calendar
+// of the time zone (UTC when nil). Without it there is a row at the time of every sample of every point.
+func OnGrid(interval Interval, zone *time.Location) FrameOption {
+	return func(o *frameOptions) { o.grid, o.zone = &interval, zone }
+}
+
+// FetchConcurrency sets how many histories FetchFrame reads at the same time. It defaults to 4.
+func FetchConcurrency(n int) FrameOption {
+	return func(o *frameOptions) { o.concurrency = n }
+}
+
+// WithHistoryOptions sets the options FetchFrame uses to read each history (ex: buildingx.HistoryChunk)
+func WithHistoryOptions(opts ...buildingx.HistoryOption) FrameOption {
+	return func(o *frameOptions) { o.history = opts }
+}
+
+// FetchFrame reads the history of the points from start to end concurrently and aligns it into a frame. The
+// first error stops the reads still in progress.
+func FetchFrame(ctx context.Context, reader HistoryReader, points []buildingx.Point, start, end time.Time, opts ...FrameOption) (*Frame, error) {
+
+	o := newFrameOptions(opts)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	series := make([]Series, len(points))
+	errs := make([]error, len(points))
+	wg := sync.WaitGroup{}
+	slots := make(chan struct{}, o.concurrency)
+
+	for i := range points {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() { <-slots; wg.Done() }()
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+			records, err := reader.GetPointHistoryRecordsWithContext(ctx, &points[i], start, end, o.history...)
+			if err != nil {
+				errs[i] = fmt.Errorf("error reading the history of point %s: %w", points[i].ID, err)
+				cancel()
+				return
+			}
+			series[i] = FromRecords(records)
+		}(i)
+	}
+	wg.Wait()
+
+	// report the error that caused the others
+	for _, err := range errs {
+		if err != nil && err != context.Canceled {
+			return nil, err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return Align(points, series, start, end, opts...)
+
+}
+
+// Align lines up the series of the points, in the same order, on a common time axis from start to end
+func Align(points []buildingx.Point, series []Series, start, end time.Time, opts ...FrameOption) (*Frame, error) {
+
+	if len(points) != len(series) {
+		return nil, fmt.Errorf("%d points were given for %d series", len(points), len(series))
+	}
+	o := newFrameOptions(opts)
+
+	frame := &Frame{Zone: time.UTC}
+	if o.grid != nil {
+		if err := o.grid.validate(); err != nil {
+			return nil, err
+		}
+		frame.Zone = o.zone
+		for t := o.grid.floor(start, o.zone); t.Before(end); t = o.grid.next(t, o.zone) {
+			if !t.Before(start) {
+				frame.Times = append(frame.Times, t)
+			}
+		}
+	} else {
+		frame.Times = sampleTimes(series, start, end)
+	}
+
+	for i, point := range points {
+		column := Column{
+			PointID: point.ID,
+			Name:    point.Name,
+			Units:   point.Units,
+			Values:  make([]float64, len(frame.Times)),
+			Valid:   make([]bool, len(frame.Times)),
+		}
+		kind := buildingx.KindOf(point.DataType)
+		interpolate := o.method == Interpolate && kind != buildingx.KindBool && kind != buildingx.KindEnum
+		for j, t := range frame.Times {
+			if interpolate {
+				column.Values[j], column.Valid[j] = series[i].interpolate(t)
+			} else {
+				column.Values[j], column.Valid[j] = series[i].at(t)
+			}
+		}
+		frame.Columns = append(frame.Columns, column)
+	}
+
+	return frame, nil
+
+}
+
+func newFrameOptions(opts []FrameOption) frameOptions {
+
+	o := frameOptions{concurrency: defaultFetchConcurrency}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.concurrency < 1 {
+		o.concurrency = 1
+	}
+	if o.zone == nil {
+		o.zone = time.UTC
+	}
+	return o
+
+}
+
+// sampleTimes returns the distinct times of the samples from start to end, in order
+func sampleTimes(series []Series, start, end time.Time) []time.Time {
+
+	seen := make(map[int64]bool)
+	times := make([]time.Time, 0)
+	for _, s := range series {
+		for _, sample := range s[s.from(start):s.after(end)] {
+			if !seen[sample.Time.UnixNano()] {
+				seen[sample.Time.UnixNano()] = true
+				times = append(times, sample.Time.UTC())
+			}
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+
+}
+
+// Labels returns the header of each column: the point name, or the point ID when the name is empty or shared
+// by another column
+func (f *Frame) Labels() []string {
+
+	names := make(map[string]int)
+	for _, column := range f.Columns {
+		names[column.Name]++
+	}
+	labels := make([]string, len(f.Columns))
+	for i, column := range f.Columns {
+		labels[i] = column.Name
+		if column.Name == "" || names[column.Name] > 1 {
+			labels[i] = column.PointID
+		}
+	}
+	return labels
+
+}
+
+// WriteCSV writes the frame as CSV with a header row. The first column is the time in RFC 3339 format and
+// the values of a point are empty where it has no value.
+func (f *Frame) WriteCSV(w io.Writer) error {
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append([]string{"timestamp"}, f.Labels()...)); err != nil {
+		return err
+	}
+
+	row := make([]string, len(f.Columns)+1)
+	for i, t := range f.Times {
+		row[0] = t.In(f.zone()).Format(time.RFC3339Nano)
+		for j, column := range f.Columns {
+			row[j+1] = ""
+			if column.Valid[i] {
+				row[j+1] = strconv.FormatFloat(column.Values[i], 'f', -1, 64)
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+
+}
+
+// WriteJSON writes the frame as a JSON document holding the times and, for each point, its ID, name, units
+// and values. A value is null where the point has no value.
+func (f *Frame) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(f)
+}
+
+type frameJSON struct {
+	Timestamps []string     `json:"timestamps"`
+	Columns    []columnJSON `json:"columns"`
+}
+
+type columnJSON struct {
+	PointID string     `json:"pointId"`
+	Name    string     `json:"name"`
+	Units   string     `json:"units,omitempty"`
+	Values  []*float64 `json:"values"`
+}
+
+// MarshalJSON encodes the frame in the form written by WriteJSON
+func (f *Frame) MarshalJSON() ([]byte, error) {
+
+	doc := frameJSON{Timestamps: make([]string, len(f.Times)), Columns: make([]columnJSON, len(f.Columns))}
+	for i, t := range f.Times {
+		doc.Timestamps[i] = t.In(f.zone()).Format(time.RFC3339Nano)
+	}
+	for i, column := range f.Columns {
+		values := make([]*float64, len(column.Values))
+		for j := range column.Values {
+			if column.Valid[j] {
+				values[j] = &column.Values[j]
+			}
+		}
+		doc.Columns[i] = columnJSON{PointID: column.PointID, Name: column.Name, Units: column.Units, Values: values}
+	}
+	return json.Marshal(doc)
+
+}
+
+func (f *Frame) zone() *time.Location {
+
+	if f.Zone == nil {
+		return time.UTC
+	}
+	return f.Zone
+
+}
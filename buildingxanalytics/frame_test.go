@@ -0,0 +1,140 @@
+package buildingxanalytics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ HistoryReader = (*buildingx.Client)(nil)
+
+// fakeReader returns the records of each point by ID and counts the reads in progress
+type fakeReader struct {
+	records map[string][]buildingx.HistoryRecord
+	mu      sync.Mutex
+	active  int
+	peak    int
+}
+
+func (r *fakeReader) GetPointHistoryRecordsWithContext(ctx context.Context, point *buildingx.Point, start, end time.Time, opts ...buildingx.HistoryOption) ([]buildingx.HistoryRecord, error) {
+
+	r.mu.Lock()
+	r.active++
+	if r.active > r.peak {
+		r.peak = r.active
+	}
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.active--
+		r.mu.Unlock()
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	records, ok := r.records[point.ID]
+	if !ok {
+		return nil, errors.New("no history")
+	}
+	return records, nil
+
+}
+
+func TestFrame(t *testing.T) {
+
+	points := []buildingx.Point{
+		{ID: "sat", Name: "Supply Air Temp", Units: "°F", DataType: buildingx.DataTypeNumber},
+		{ID: "sp", Name: "Setpoint", Units: "°F", DataType: buildingx.DataTypeNumber},
+		{ID: "fan", Name: "Fan Status", DataType: buildingx.DataTypeBoolean},
+	}
+	reader := &fakeReader{records: map[string][]buildingx.HistoryRecord{
+		"sat": {{Time: at(0), Value: buildingx.NumberValue(60)}, {Time: at(20), Value: buildingx.NumberValue(56)}},
+		"sp":  {{Time: at(-60), Value: buildingx.NumberValue(55)}},
+		"fan": {{Time: at(10), Value: buildingx.BoolValue(true)}, {Time: at(30), Value: buildingx.BoolValue(false)}},
+	}}
+
+	t.Run("sample-and-hold", func(t *testing.T) {
+		frame, err := FetchFrame(context.Background(), reader, points, midnight, at(60))
+		if err != nil {
+			t.Fatal("error fetching frame: ", err.Error())
+		}
+		assert.Equal(t, []time.Time{at(0), at(10), at(20), at(30)}, frame.Times)
+		assert.Equal(t, []float64{60, 60, 56, 56}, frame.Columns[0].Values)
+		assert.Equal(t, []float64{55, 55, 55, 55}, frame.Columns[1].Values)
+		assert.Equal(t, []bool{false, true, true, true}, frame.Columns[2].Valid)
+		assert.Equal(t, []string{"Supply Air Temp", "Setpoint", "Fan Status"}, frame.Labels())
+	})
+	t.Run("interpolated-on-grid", func(t *testing.T) {
+		frame, err := FetchFrame(context.Background(), reader, points, midnight, at(30), AlignMethod(Interpolate), OnGrid(Every(5*time.Minute), nil))
+		if err != nil {
+			t.Fatal("error fetching frame: ", err.Error())
+		}
+		assert.Equal(t, 6, len(frame.Times))
+		assert.Equal(t, []float64{60, 59, 58, 57, 56, 0}, frame.Columns[0].Values)
+		assert.Equal(t, []bool{true, true, true, true, true, false}, frame.Columns[0].Valid)
+		// the setpoint has no sample after the range to interpolate towards
+		assert.Equal(t, []bool{false, false, false, false, false, false}, frame.Columns[1].Valid)
+		// the fan status is held rather than interpolated
+		assert.Equal(t, []float64{0, 0, 1, 1, 1, 1}, frame.Columns[2].Values)
+	})
+	t.Run("aliased-data-types-are-held", func(t *testing.T) {
+		aliased := []buildingx.Point{{ID: "fan", DataType: "Boolean"}, {ID: "mode", DataType: "multistate"}}
+		series := []Series{
+			FromRecords(reader.records["fan"]),
+			FromRecords([]buildingx.HistoryRecord{{Time: at(0), Value: buildingx.EnumValue(1)}, {Time: at(20), Value: buildingx.EnumValue(3)}}),
+		}
+		frame, err := Align(aliased, series, midnight, at(30), AlignMethod(Interpolate), OnGrid(Every(5*time.Minute), nil))
+		if err != nil {
+			t.Fatal("error aligning frame: ", err.Error())
+		}
+		assert.Equal(t, []float64{0, 0, 1, 1, 1, 1}, frame.Columns[0].Values)
+		assert.Equal(t, []float64{1, 1, 1, 1, 3, 3}, frame.Columns[1].Values)
+	})
+	t.Run("concurrency", func(t *testing.T) {
+		many := make([]buildingx.Point, 12)
+		for i := range many {
+			many[i] = points[i%3]
+		}
+		counted := &fakeReader{records: reader.records}
+		frame, err := FetchFrame(context.Background(), counted, many, midnight, at(60), FetchConcurrency(3))
+		assert.Nil(t, err)
+		assert.Equal(t, 12, len(frame.Columns))
+		assert.True(t, counted.peak > 1 && counted.peak <= 3)
+		// names shared by several columns fall back to the point ID
+		assert.Equal(t, "sat", frame.Labels()[0])
+	})
+	t.Run("error", func(t *testing.T) {
+		_, err := FetchFrame(context.Background(), reader, append(points, buildingx.Point{ID: "missing"}), midnight, at(60))
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "missing")
+
+		_, err = Align(points, []Series{nil}, midnight, at(60))
+		assert.NotNil(t, err)
+	})
+	t.Run("export", func(t *testing.T) {
+		frame, err := FetchFrame(context.Background(), reader, points, midnight, at(60))
+		if err != nil {
+			t.Fatal("error fetching frame: ", err.Error())
+		}
+		frame.Times, frame.Columns = frame.Times[:2], frame.Columns[1:]
+		for i := range frame.Columns {
+			frame.Columns[i].Values, frame.Columns[i].Valid = frame.Columns[i].Values[:2], frame.Columns[i].Valid[:2]
+		}
+
+		csv := bytes.Buffer{}
+		assert.Nil(t, frame.WriteCSV(&csv))
+		assert.Equal(t, "timestamp,Setpoint,Fan Status\n2022-05-01T00:00:00Z,55,\n2022-05-01T00:10:00Z,55,1\n", csv.String())
+
+		doc := bytes.Buffer{}
+		assert.Nil(t, frame.WriteJSON(&doc))
+		assert.JSONEq(t, `{"timestamps":["2022-05-01T00:00:00Z","2022-05-01T00:10:00Z"],"columns":[
+			{"pointId":"sp","name":"Setpoint","units":"°F","values":[55,55]},
+			{"pointId":"fan","name":"Fan Status","values":[null,1]}]}`, doc.String())
+	})
+
+}
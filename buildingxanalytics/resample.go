@@ -0,0 +1,339 @@
+package buildingxanalytics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+)
+
+// Aggregation is how the samples of a bucket are combined into its value
+type Aggregation int
+
+const (
+	// Avg is the mean of the samples recorded in the bucket
+	Avg Aggregation = iota + 1
+	// Min is the lowest sample recorded in the bucket
+	Min
+	// Max is the highest sample recorded in the bucket
+	Max
+	// First is the first sample recorded in the bucket
+	First
+	// Last is the last sample recorded in the bucket
+	Last
+	// Count is the number of samples recorded in the bucket. It is never filled.
+	Count
+	// TimeWeightedAvg is the mean of the value held over the bucket, where each value counts for as long as
+	// it held, including the value carried into the bucket from before it
+	TimeWeightedAvg
+	// OnTime is the number of seconds of the bucket during which the value was not zero, which for a boolean
+	// point is the time it was on
+	OnTime
+)
+
+func (a Aggregation) String() string {
+
+	switch a {
+	case Avg:
+		return "avg"
+	case Min:
+		return "min"
+	case Max:
+		return "max"
+	case First:
+		return "first"
+	case Last:
+		return "last"
+	case Count:
+		return "count"
+	case TimeWeightedAvg:
+		return "twavg"
+	case OnTime:
+		return "ontime"
+	}
+	return "unknown"
+
+}
+
+// Fill is what a bucket holds when it has no value of its own
+type Fill int
+
+const (
+	// FillNull leaves the bucket without a value (Valid is false)
+	FillNull Fill = iota
+	// FillPrevious uses the value held at the start of the bucket, which is the last sample before it
+	FillPrevious
+	// FillLinear interpolates between the samples around the start of the bucket
+	FillLinear
+)
+
+// Interval is the size of the buckets. Buckets are aligned on the calendar of the time zone: hourly buckets
+// start on the hour, daily buckets at midnight, weekly buckets on Monday and monthly buckets on the first.
+type Interval struct {
+	every  time.Duration
+	days   int
+	months int
+}
+
+// Every returns buckets of a fixed duration of up to 24 hours, aligned from midnight (ex: 15 minutes or 1 hour).
+// The last bucket of a day ends at midnight when the duration does not divide the day.
+func Every(d time.Duration) Interval {
+	return Interval{every: d}
+}
+
+// Hourly returns one hour buckets
+func Hourly() Interval {
+	return Every(time.Hour)
+}
+
+// Daily returns buckets from midnight to midnight, which last 23 or 25 hours on daylight saving time changes
+func Daily() Interval {
+	return Interval{days: 1}
+}
+
+// Weekly returns buckets from Monday to Monday
+func Weekly() Interval {
+	return Interval{days: 7}
+}
+
+// Monthly returns calendar month buckets
+func Monthly() Interval {
+	return Interval{months: 1}
+}
+
+func (i Interval) validate() error {
+
+	if i.days == 0 && i.months == 0 && (i.every <= 0 || i.every > 24*time.Hour) {
+		return fmt.Errorf("interval of %s is not between 0 and 24h: use Daily, Weekly or Monthly", i.every)
+	}
+	return nil
+
+}
+
+// floor returns the start of the bucket holding t
+func (i Interval) floor(t time.Time, zone *time.Location) time.Time {
+
+	t = t.In(zone)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, zone)
+	switch {
+	case i.months > 0:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, zone)
+	case i.days == 7:
+		return midnight.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	case i.days > 0:
+		return midnight
+	}
+	return midnight.Add(t.Sub(midnight) / i.every * i.every)
+
+}
+
+// next returns the start of the bucket after the one starting at t
+func (i Interval) next(t time.Time, zone *time.Location) time.Time {
+
+	t = t.In(zone)
+	if i.months > 0 || i.days > 0 {
+		return t.AddDate(0, i.months, i.days)
+	}
+	next := t.Add(i.every)
+	if midnight := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, zone); next.After(midnight) {
+		return midnight
+	}
+	return next
+
+}
+
+// Bucket is the aggregated value of a series over an interval
+type Bucket struct {
+	Start time.Time
+	End   time.Time
+	// Value is the aggregated value. It is only meaningful when Valid is set.
+	Value float64
+	// Valid is false when the bucket has no value and the fill policy did not give it one
+	Valid bool
+	// Filled is set when the value comes from the fill policy rather than from the samples of the bucket
+	Filled bool
+	// Count is the number of samples recorded in the bucket
+	Count int
+}
+
+// Option changes how a series is resampled
+type Option func(*options)
+
+type options struct {
+	fill Fill
+	zone *time.Location
+}
+
+// WithFill sets what buckets without a value hold. It defaults to FillNull.
+func WithFill(fill Fill) Option {
+	return func(o *options) { o.fill = fill }
+}
+
+// InZone aligns the buckets on the calendar of the time zone. It defaults to UTC.
+func InZone(zone *time.Location) Option {
+	return func(o *options) { o.zone = zone }
+}
+
+// ZoneOf loads the time zone of a location, so that buckets can be aligned on its calendar with InZone
+func ZoneOf(location buildingx.Location) (*time.Location, error) {
+
+	if location.TimeZone == "" {
+		return nil, errors.New("location " + location.ID + " has no time zone")
+	}
+	zone, err := time.LoadLocation(location.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the time zone of location %s: %w", location.ID, err)
+	}
+	return zone, nil
+
+}
+
+// Resample aggregates the series into buckets of the interval from start to end. The first bucket is the one
+// holding start and the last one is the one holding the instant before end. Only the samples from start to
+// end count, but the value held at start (from an earlier sample) is used by the time-weighted aggregations
+// and the fill policies.
+func Resample(series Series, start, end time.Time, interval Interval, aggregation Aggregation, opts ...Option) ([]Bucket, error) {
+
+	o := options{zone: time.UTC}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.zone == nil {
+		o.zone = time.UTC
+	}
+	if err := interval.validate(); err != nil {
+		return nil, err
+	}
+	if aggregation < Avg || aggregation > OnTime {
+		return nil, fmt.Errorf("unknown aggregation %d", aggregation)
+	}
+
+	buckets := make([]Bucket, 0)
+	for bucketStart := interval.floor(start, o.zone); bucketStart.Before(end); {
+
+		bucketEnd := interval.next(bucketStart, o.zone)
+		bucket := Bucket{Start: bucketStart, End: bucketEnd}
+
+		// the part of the bucket inside the range
+		lo, hi := bucketStart, bucketEnd
+		if lo.Before(start) {
+			lo = start
+		}
+		if hi.After(end) {
+			hi = end
+		}
+
+		aggregate(series, lo, hi, aggregation, &bucket)
+		if !bucket.Valid && aggregation != Count {
+			fill(series, lo, o.fill, &bucket)
+		}
+
+		buckets = append(buckets, bucket)
+		bucketStart = bucketEnd
+
+	}
+
+	return buckets, nil
+
+}
+
+// aggregate sets the value of the bucket from the samples from lo (included) to hi (excluded)
+func aggregate(series Series, lo, hi time.Time, aggregation Aggregation, bucket *Bucket) {
+
+	first, last := series.from(lo), series.from(hi)
+	samples := series[first:last]
+	bucket.Count = len(samples)
+
+	switch aggregation {
+	case Count:
+		bucket.Value, bucket.Valid = float64(len(samples)), true
+		return
+	case TimeWeightedAvg, OnTime:
+		weighted(series, lo, hi, aggregation, bucket)
+		return
+	}
+
+	if len(samples) == 0 {
+		return
+	}
+	bucket.Valid = true
+	switch aggregation {
+	case First:
+		bucket.Value = samples[0].Value
+	case Last:
+		bucket.Value = samples[len(samples)-1].Value
+	case Min:
+		bucket.Value = math.Inf(1)
+		for _, sample := range samples {
+			bucket.Value = math.Min(bucket.Value, sample.Value)
+		}
+	case Max:
+		bucket.Value = math.Inf(-1)
+		for _, sample := range samples {
+			bucket.Value = math.Max(bucket.Value, sample.Value)
+		}
+	case Avg:
+		sum := 0.0
+		for _, sample := range samples {
+			sum += sample.Value
+		}
+		bucket.Value = sum / float64(len(samples))
+	}
+
+}
+
+// weighted integrates the value held from lo to hi. The bucket has no value when nothing was held over it.
+func weighted(series Series, lo, hi time.Time, aggregation Aggregation, bucket *Bucket) {
+
+	value, held := series.at(lo)
+	at := lo
+	area, on, covered := 0.0, 0.0, 0.0
+
+	hold := func(until time.Time) {
+		if !held {
+			return
+		}
+		seconds := until.Sub(at).Seconds()
+		area += value * seconds
+		covered += seconds
+		if value != 0 {
+			on += seconds
+		}
+	}
+
+	for i := series.after(lo); i < len(series) && series[i].Time.Before(hi); i++ {
+		hold(series[i].Time)
+		value, held, at = series[i].Value, true, series[i].Time
+	}
+	hold(hi)
+
+	if covered <= 0 {
+		return
+	}
+	bucket.Valid = true
+	if aggregation == OnTime {
+		bucket.Value = on
+	} else {
+		bucket.Value = area / covered
+	}
+
+}
+
+// fill gives a value to a bucket that has none, according to the fill policy
+func fill(series Series, lo time.Time, policy Fill, bucket *Bucket) {
+
+	var value float64
+	var ok bool
+	switch policy {
+	case FillPrevious:
+		value, ok = series.at(lo)
+	case FillLinear:
+		value, ok = series.interpolate(lo)
+	}
+	if ok {
+		bucket.Value, bucket.Valid, bucket.Filled = value, true, true
+	}
+
+}
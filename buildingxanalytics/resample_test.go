@@ -0,0 +1,151 @@
+package buildingxanalytics
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+	"github.com/stretchr/testify/assert"
+)
+
+var midnight = time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+
+// at returns the time the given number of minutes after midnight
+func at(minutes int) time.Time {
+	return midnight.Add(time.Duration(minutes) * time.Minute)
+}
+
+func values(buckets []Bucket) []interface{} {
+
+	result := make([]interface{}, len(buckets))
+	for i, bucket := range buckets {
+		if bucket.Valid {
+			result[i] = bucket.Value
+		}
+	}
+	return result
+
+}
+
+func TestResample(t *testing.T) {
+
+	// a temperature recorded twice in the first hour, not at all in the second and once in the third
+	temperature := Series{{at(10), 70}, {at(40), 74}, {at(150), 80}}
+
+	t.Run("sample-aggregations", func(t *testing.T) {
+		expected := map[Aggregation][]interface{}{
+			Avg:   {72.0, nil, 80.0},
+			Min:   {70.0, nil, 80.0},
+			Max:   {74.0, nil, 80.0},
+			First: {70.0, nil, 80.0},
+			Last:  {74.0, nil, 80.0},
+			Count: {2.0, 0.0, 1.0},
+		}
+		for aggregation, want := range expected {
+			buckets, err := Resample(temperature, midnight, at(180), Hourly(), aggregation)
+			assert.Nil(t, err)
+			assert.Equal(t, want, values(buckets), aggregation.String())
+		}
+	})
+	t.Run("fill-policies", func(t *testing.T) {
+		buckets, _ := Resample(temperature, midnight, at(180), Hourly(), Avg, WithFill(FillPrevious))
+		assert.Equal(t, []interface{}{72.0, 74.0, 80.0}, values(buckets))
+		assert.True(t, buckets[1].Filled)
+		assert.Equal(t, 0, buckets[1].Count)
+
+		// 01:00 is 20 of the 110 minutes from 74 at 00:40 to 80 at 02:30
+		buckets, _ = Resample(temperature, midnight, at(180), Hourly(), Avg, WithFill(FillLinear))
+		assert.InDelta(t, 74+6*20.0/110, buckets[1].Value, 1e-9)
+
+		buckets, _ = Resample(temperature, at(-60), midnight, Hourly(), Max, WithFill(FillPrevious))
+		assert.Equal(t, []interface{}{nil}, values(buckets))
+	})
+	t.Run("time-weighted", func(t *testing.T) {
+		buckets, err := Resample(temperature, midnight, at(180), Hourly(), TimeWeightedAvg)
+		assert.Nil(t, err)
+		// 70 for 30 minutes and 74 for 20 minutes, then 74 held all hour, then 74 for 30 minutes and 80 for 30
+		assert.InDelta(t, (70*30+74*20)/50.0, buckets[0].Value, 1e-9)
+		assert.Equal(t, 74.0, buckets[1].Value)
+		assert.False(t, buckets[1].Filled)
+		assert.Equal(t, 77.0, buckets[2].Value)
+	})
+	t.Run("on-time", func(t *testing.T) {
+		fan := Series{{at(-30), 1}, {at(45), 0}, {at(120), 1}, {at(150), 0}}
+		buckets, err := Resample(fan, midnight, at(24*60), Daily(), OnTime)
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{float64(75 * 60)}, values(buckets))
+
+		buckets, _ = Resample(fan, midnight, at(180), Hourly(), OnTime)
+		assert.Equal(t, []interface{}{45 * 60.0, 0.0, 30 * 60.0}, values(buckets))
+	})
+	t.Run("aligned-in-the-location-time-zone", func(t *testing.T) {
+		zone, err := ZoneOf(buildingx.Location{ID: "location-1", TimeZone: "America/Chicago"})
+		if err != nil {
+			t.Fatal("error loading zone: ", err.Error())
+		}
+		// daylight saving time starts on March 13, 2022 in Chicago
+		start := time.Date(2022, 3, 12, 12, 0, 0, 0, zone)
+		buckets, err := Resample(Series{{start, 1}}, start, start.Add(48*time.Hour), Daily(), Count, InZone(zone))
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(buckets))
+		assert.Equal(t, time.Date(2022, 3, 12, 0, 0, 0, 0, zone), buckets[0].Start)
+		assert.Equal(t, 23*time.Hour, buckets[1].End.Sub(buckets[1].Start))
+		assert.Equal(t, 23, len(mustResample(t, nil, buckets[1].Start, buckets[1].End, Hourly(), zone)))
+
+		// a 5 hour interval restarts at midnight
+		buckets = mustResample(t, nil, buckets[0].Start, buckets[0].End, Every(5*time.Hour), zone)
+		assert.Equal(t, 5, len(buckets))
+		assert.Equal(t, 4*time.Hour, buckets[4].End.Sub(buckets[4].Start))
+
+		buckets = mustResample(t, nil, start, start.Add(time.Hour), Weekly(), zone)
+		assert.Equal(t, time.Monday, buckets[0].Start.Weekday())
+		buckets = mustResample(t, nil, start, start.Add(time.Hour), Monthly(), zone)
+		assert.Equal(t, time.Date(2022, 4, 1, 0, 0, 0, 0, zone), buckets[0].End)
+
+		_, err = ZoneOf(buildingx.Location{ID: "location-2"})
+		assert.NotNil(t, err)
+	})
+	t.Run("invalid-interval", func(t *testing.T) {
+		_, err := Resample(temperature, midnight, at(180), Every(0), Avg)
+		assert.NotNil(t, err)
+		_, err = Resample(temperature, midnight, at(180), Every(48*time.Hour), Avg)
+		assert.NotNil(t, err)
+	})
+
+}
+
+func mustResample(t *testing.T, series Series, start, end time.Time, interval Interval, zone *time.Location) []Bucket {
+
+	buckets, err := Resample(series, start, end, interval, Count, InZone(zone))
+	if err != nil {
+		t.Fatal("error resampling: ", err.Error())
+	}
+	return buckets
+
+}
+
+func TestSeries(t *testing.T) {
+
+	t.Run("from-records", func(t *testing.T) {
+		series := FromRecords([]buildingx.HistoryRecord{
+			{Time: at(2), Value: buildingx.NumberValue(72.5)},
+			{Time: at(1), Value: buildingx.BoolValue(true)},
+			{Time: at(3), Value: buildingx.EnumValue(3)},
+			{Time: at(4), Value: buildingx.TextValue("fault")},
+		})
+		assert.Equal(t, Series{{at(1), 1}, {at(2), 72.5}, {at(3), 3}}, series)
+	})
+	t.Run("from-history", func(t *testing.T) {
+		series, err := FromHistory([]buildingx.PointHistory{
+			{Value: "inactive", Timestamp: "2022-05-01T00:01:00Z"},
+			{Value: "unknown", Timestamp: "2022-05-01T00:02:00Z"},
+		}, buildingx.DataTypeBoolean)
+		assert.Nil(t, err)
+		assert.Equal(t, Series{{at(1), 0}}, series)
+
+		_, err = FromHistory([]buildingx.PointHistory{{Value: "1", Timestamp: "noon"}}, buildingx.DataTypeNumber)
+		assert.NotNil(t, err)
+	})
+
+}
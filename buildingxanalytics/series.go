@@ -0,0 +1,118 @@
+// Package buildingxanalytics turns the history of Building X points into reports. Raw change-of-value records
+// are converted to a Series, which Resample aggregates into fixed-interval buckets aligned in the time zone of
+// the location (hourly or daily averages, minimums, maximums, on-time totals and so on). FetchFrame and Align
+// line up the history of several points on a common time axis.
+package buildingxanalytics
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+)
+
+// Sample is a value of a point at a point in time. Boolean values are 1 (true) or 0 (false) and enumerated
+// values are their state number.
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is the history of a point in chronological order. Like the Building X history, it is a record of
+// changes: each value holds until the time of the next sample.
+type Series []Sample
+
+// FromRecords returns the series of history records. Records whose value is not a boolean, number or
+// enumeration (ex: a status text) are left out.
+func FromRecords(records []buildingx.HistoryRecord) Series {
+
+	series := make(Series, 0, len(records))
+	for _, record := range records {
+		if f, ok := numeric(record.Value); ok {
+			series = append(series, Sample{Time: record.Time, Value: f})
+		}
+	}
+	series.sort()
+	return series
+
+}
+
+// FromHistory returns the series of raw history records of a point with the given data type. Records that do
+// not match the data type are left out, and a record whose timestamp cannot be parsed is an error.
+func FromHistory(history []buildingx.PointHistory, dataType string) (Series, error) {
+
+	series := make(Series, 0, len(history))
+	for _, h := range history {
+		timestamp, err := time.Parse(time.RFC3339Nano, h.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse history timestamp %q: %w", h.Timestamp, err)
+		}
+		value, err := buildingx.ParseValue(dataType, h.Value)
+		if err != nil {
+			continue
+		}
+		if f, ok := numeric(value); ok {
+			series = append(series, Sample{Time: timestamp, Value: f})
+		}
+	}
+	series.sort()
+	return series, nil
+
+}
+
+// numeric converts a typed value to a float, reporting false for string values
+func numeric(value buildingx.Value) (float64, bool) {
+
+	if value.Kind() == buildingx.KindBool {
+		b, _ := value.Bool()
+		if b {
+			return 1, true
+		}
+		return 0, true
+	}
+	f, err := value.Float()
+	return f, err == nil
+
+}
+
+func (s Series) sort() {
+	sort.SliceStable(s, func(i, j int) bool { return s[i].Time.Before(s[j].Time) })
+}
+
+// at returns the value held at t, which is the value of the last sample at or before t
+func (s Series) at(t time.Time) (float64, bool) {
+
+	i := s.after(t)
+	if i == 0 {
+		return 0, false
+	}
+	return s[i-1].Value, true
+
+}
+
+// after returns the index of the first sample after t
+func (s Series) after(t time.Time) int {
+	return sort.Search(len(s), func(i int) bool { return s[i].Time.After(t) })
+}
+
+// from returns the index of the first sample at or after t
+func (s Series) from(t time.Time) int {
+	return sort.Search(len(s), func(i int) bool { return !s[i].Time.Before(t) })
+}
+
+// interpolate returns the value at t on the line between the samples around it
+func (s Series) interpolate(t time.Time) (float64, bool) {
+
+	i := s.from(t)
+	if i < len(s) && s[i].Time.Equal(t) {
+		return s[i].Value, true
+	}
+	if i == 0 || i == len(s) {
+		return 0, false
+	}
+	before, next := s[i-1], s[i]
+	fraction := float64(t.Sub(before.Time)) / float64(next.Time.Sub(before.Time))
+	return before.Value + fraction*(next.Value-before.Value), true
+
+}
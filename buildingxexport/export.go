@@ -0,0 +1,279 @@
+// Package buildingxexport writes the history of Building X points to files for analysis in other tools. Each
+// record becomes a row carrying the point metadata (ID, name, device, location and units), written as CSV,
+// JSON Lines or Parquet, or encoded for a time-series database as InfluxDB line protocol, OpenMetrics or a
+// Prometheus remote-write request. Histories are streamed from the API, so an export does not hold every row
+// in memory.
+//
+// It lives in its own module so that projects which do not export history do not depend on Parquet.
+package buildingxexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+)
+
+// Columns are the names of the columns of an export, in order. The JSON Lines fields and the Parquet columns
+// have the same names.
+var Columns = []string{"timestamp", "time_zone", "point_id", "point_name", "device", "location", "units", "value", "number"}
+
+// Source is a point to export along with the device and the location it belongs to
+type Source struct {
+	Point    buildingx.Point
+	Device   buildingx.Device
+	Location buildingx.Location
+}
+
+// Row is a history record of a point along with the metadata of the point
+type Row struct {
+	// Time is when the value was recorded, in the time zone of the export
+	Time      time.Time
+	PointID   string
+	PointName string
+	Device    string
+	Location  string
+	Units     string
+	// Value is the value as returned by the API
+	Value string
+	// Number is the numeric value according to the data type of the point: booleans are 1 or 0 and enumerated
+	// values are their state number. It is nil for text values.
+	Number *float64
+}
+
+// NewRow returns the row of a history record of the source, with the time in the zone (UTC when nil)
+func NewRow(source Source, record buildingx.HistoryRecord, zone *time.Location) Row {
+
+	if zone == nil {
+		zone = time.UTC
+	}
+	row := Row{
+		Time:      record.Time.In(zone),
+		PointID:   source.Point.ID,
+		PointName: source.Point.Name,
+		Device:    source.Device.Name,
+		Location:  source.Location.Name,
+		Units:     source.Point.Units,
+		Value:     record.Raw,
+	}
+	switch record.Value.Kind() {
+	case buildingx.KindBool:
+		b, _ := record.Value.Bool()
+		number := 0.0
+		if b {
+			number = 1
+		}
+		row.Number = &number
+	case buildingx.KindNumber, buildingx.KindEnum:
+		number, _ := record.Value.Float()
+		row.Number = &number
+	}
+	return row
+
+}
+
+// NewPointRow returns the row of the current value of the source point, recorded at the timestamp of the point
+func NewPointRow(source Source, zone *time.Location) Row {
+
+	value, err := source.Point.TypedValue()
+	if err != nil {
+		value = buildingx.TextValue(source.Point.StringValue)
+	}
+	return NewRow(source, buildingx.HistoryRecord{Time: source.Point.Timestamp, Value: value, Raw: source.Point.StringValue}, zone)
+
+}
+
+// RowWriter writes rows in a file format. Close flushes what is buffered and completes the format, but does
+// not close the underlying writer.
+type RowWriter interface {
+	WriteRow(row Row) error
+	Close() error
+}
+
+// Format is a file format of an export
+type Format int
+
+const (
+	// CSV writes a header and a line per row. Timestamps are in RFC 3339 format with the offset of the time zone.
+	CSV Format = iota
+	// JSONLines writes a JSON object per line
+	JSONLines
+	// Parquet writes a Parquet file compressed with Snappy. Timestamps are stored as instants and the
+	// time_zone column holds the zone of the export.
+	Parquet
+)
+
+func (f Format) String() string {
+
+	switch f {
+	case CSV:
+		return "csv"
+	case JSONLines:
+		return "jsonl"
+	case Parquet:
+		return "parquet"
+	}
+	return "unknown"
+
+}
+
+// FormatOf returns the format of a file from its extension: .csv, .jsonl (or .ndjson) or .parquet
+func FormatOf(path string) (Format, error) {
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return CSV, nil
+	case ".jsonl", ".ndjson":
+		return JSONLines, nil
+	case ".parquet":
+		return Parquet, nil
+	}
+	return 0, fmt.Errorf("unknown export format for %s: use .csv, .jsonl or .parquet", path)
+
+}
+
+// NewWriter returns a writer of rows in the format
+func NewWriter(format Format, w io.Writer) (RowWriter, error) {
+
+	switch format {
+	case CSV:
+		return NewCSVWriter(w), nil
+	case JSONLines:
+		return NewJSONLinesWriter(w), nil
+	case Parquet:
+		return NewParquetWriter(w), nil
+	}
+	return nil, fmt.Errorf("unknown export format %d", format)
+
+}
+
+// HistoryStreamer streams the history of a point. *buildingx.Client implements it, and SessionStreamer adapts
+// a session for the package-level functions.
+type HistoryStreamer interface {
+	StreamPointHistoryWithContext(ctx context.Context, point *buildingx.Point, start, end time.Time, opts ...buildingx.HistoryOption) *buildingx.HistoryIterator
+}
+
+// SessionStreamer returns a HistoryStreamer that streams through the package-level functions with the session
+func SessionStreamer(session *buildingx.Session) HistoryStreamer {
+	return sessionStreamer{session: session}
+}
+
+type sessionStreamer struct {
+	session *buildingx.Session
+}
+
+func (s sessionStreamer) StreamPointHistoryWithContext(ctx context.Context, point *buildingx.Point, start, end time.Time, opts ...buildingx.HistoryOption) *buildingx.HistoryIterator {
+	return buildingx.StreamPointHistoryWithContext(ctx, s.session, point, start, end, opts...)
+}
+
+// Option changes how history is exported
+type Option func(*options)
+
+type options struct {
+	local   bool
+	history []buildingx.HistoryOption
+}
+
+// LocalTime writes the timestamps of each point in the time zone of its location instead of UTC
+func LocalTime() Option {
+	return func(o *options) { o.local = true }
+}
+
+// WithHistoryOptions sets the options used to read each history (ex: buildingx.HistoryChunk)
+func WithHistoryOptions(opts ...buildingx.HistoryOption) Option {
+	return func(o *options) { o.history = opts }
+}
+
+// Export writes the history of the sources from start to end to the writer, one point after the other and in
+// chronological order for each point. It does not close the writer.
+func Export(ctx context.Context, streamer HistoryStreamer, sources []Source, start, end time.Time, w RowWriter, opts ...Option) error {
+
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// load every zone before writing anything
+	zones := make([]*time.Location, len(sources))
+	for i, source := range sources {
+		zones[i] = time.UTC
+		if !o.local {
+			continue
+		}
+		if source.Location.TimeZone == "" {
+			return errors.New("location " + source.Location.ID + " of point " + source.Point.ID + " has no time zone")
+		}
+		zone, err := time.LoadLocation(source.Location.TimeZone)
+		if err != nil {
+			return fmt.Errorf("unable to load the time zone of location %s: %w", source.Location.ID, err)
+		}
+		zones[i] = zone
+	}
+
+	for i := range sources {
+		if err := exportPoint(ctx, streamer, sources[i], zones[i], start, end, w, o.history); err != nil {
+			return err
+		}
+	}
+	return nil
+
+}
+
+func exportPoint(ctx context.Context, streamer HistoryStreamer, source Source, zone *time.Location, start, end time.Time, w RowWriter, opts []buildingx.HistoryOption) error {
+
+	it := streamer.StreamPointHistoryWithContext(ctx, &source.Point, start, end, opts...)
+	defer it.Close()
+	for it.Next() {
+		if err := w.WriteRow(NewRow(source, it.Record(), zone)); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("error reading the history of point %s: %w", source.Point.ID, err)
+	}
+	return nil
+
+}
+
+// ExportFile exports the history of the sources to a file in the format of its extension (see FormatOf). The
+// file is removed when the export fails.
+func ExportFile(ctx context.Context, streamer HistoryStreamer, sources []Source, start, end time.Time, path string, opts ...Option) (err error) {
+
+	format, err := FormatOf(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	w, err := NewWriter(format, file)
+	if err != nil {
+		return err
+	}
+	if err := Export(ctx, streamer, sources, start, end, w, opts...); err != nil {
+		return err
+	}
+	return w.Close()
+
+}
+
+// timestamp formats the time of a row for the text formats
+func timestamp(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
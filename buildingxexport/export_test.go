@@ -0,0 +1,138 @@
+package buildingxexport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+	"github.com/cloudlinesolutions/buildingx-operations-api/buildingxtest"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	exportStart = time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	exportEnd   = time.Date(2022, 5, 2, 0, 0, 0, 0, time.UTC)
+)
+
+// newExporter returns a client of a fake server with the default fixtures, where the supply fan also has
+// history, and the sources of the fan and the supply air temperature
+func newExporter(t *testing.T) (*buildingx.Client, []Source) {
+
+	fixtures := buildingxtest.DefaultFixtures()
+	fixtures.History["point-1"] = []buildingx.PointHistory{
+		{Value: "active", Timestamp: "2022-05-01T09:00:00Z"},
+		{Value: "inactive", Timestamp: "2022-05-01T10:30:00Z"},
+	}
+	server := buildingxtest.NewServer(fixtures, buildingxtest.WithPageSize(3))
+	t.Cleanup(server.Close)
+	client, err := server.Client()
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+
+	sources := make([]Source, 0)
+	for _, point := range fixtures.Points[:2] {
+		sources = append(sources, Source{Point: point.Point, Device: fixtures.Devices[1].Device, Location: fixtures.Locations[0]})
+	}
+	return client, sources
+
+}
+
+func TestExport(t *testing.T) {
+
+	t.Run("csv-in-local-time", func(t *testing.T) {
+		client, sources := newExporter(t)
+		path := filepath.Join(t.TempDir(), "history.csv")
+		err := ExportFile(context.Background(), client, sources, exportStart, exportEnd, path, LocalTime())
+		if err != nil {
+			t.Fatal("error exporting: ", err.Error())
+		}
+		content, _ := os.ReadFile(path)
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		assert.Equal(t, 7, len(lines))
+		assert.Equal(t, strings.Join(Columns, ","), lines[0])
+		assert.Equal(t, "2022-05-01T04:00:00-05:00,America/Chicago,point-1,SupplyFanCmd,AHU-1,Headquarters,,active,1", lines[1])
+		assert.Equal(t, "2022-05-01T07:00:00-05:00,America/Chicago,point-2,SupplyAirTemp,AHU-1,Headquarters,°F,55.2,55.2", lines[6])
+	})
+	t.Run("json-lines", func(t *testing.T) {
+		client, sources := newExporter(t)
+		buffer := bytes.Buffer{}
+		w := NewJSONLinesWriter(&buffer)
+		if err := Export(context.Background(), client, sources[1:], exportStart, exportEnd, w); err != nil {
+			t.Fatal("error exporting: ", err.Error())
+		}
+		assert.Nil(t, w.Close())
+
+		rows := make([]map[string]interface{}, 0)
+		scanner := bufio.NewScanner(&buffer)
+		for scanner.Scan() {
+			row := make(map[string]interface{})
+			assert.Nil(t, json.Unmarshal(scanner.Bytes(), &row))
+			rows = append(rows, row)
+		}
+		assert.Equal(t, 4, len(rows))
+		assert.Equal(t, "2022-05-01T09:00:00Z", rows[0]["timestamp"])
+		assert.Equal(t, "UTC", rows[0]["time_zone"])
+		assert.Equal(t, "54.8", rows[0]["value"])
+		assert.Equal(t, 54.8, rows[0]["number"])
+	})
+	t.Run("parquet", func(t *testing.T) {
+		client, sources := newExporter(t)
+		buffer := bytes.Buffer{}
+		w := NewParquetWriter(&buffer, RowGroupSize(4))
+		if err := Export(context.Background(), client, sources, exportStart, exportEnd, w); err != nil {
+			t.Fatal("error exporting: ", err.Error())
+		}
+		assert.Nil(t, w.Close())
+
+		file, err := parquet.OpenFile(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+		if err != nil {
+			t.Fatal("error opening file: ", err.Error())
+		}
+		assert.Equal(t, 2, len(file.RowGroups()))
+		rows, err := parquet.Read[parquetRow](bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+		if err != nil {
+			t.Fatal("error reading rows: ", err.Error())
+		}
+		assert.Equal(t, 6, len(rows))
+		assert.Equal(t, time.Date(2022, 5, 1, 10, 30, 0, 0, time.UTC).UnixMicro(), rows[1].Timestamp)
+		assert.Equal(t, 0.0, *rows[1].Number)
+		assert.Equal(t, "Headquarters", rows[5].Location)
+	})
+	t.Run("text-values", func(t *testing.T) {
+		row := NewRow(Source{Point: buildingx.Point{ID: "point-5"}}, buildingx.HistoryRecord{Time: exportStart, Value: buildingx.TextValue("fault"), Raw: "fault"}, nil)
+		assert.Nil(t, row.Number)
+		buffer := bytes.Buffer{}
+		w := NewCSVWriter(&buffer)
+		assert.Nil(t, w.WriteRow(row))
+		assert.Nil(t, w.Close())
+		assert.True(t, strings.HasSuffix(buffer.String(), ",point-5,,,,,fault,\n"))
+	})
+	t.Run("errors", func(t *testing.T) {
+		client, sources := newExporter(t)
+		dir := t.TempDir()
+
+		err := ExportFile(context.Background(), client, sources, exportStart, exportEnd, filepath.Join(dir, "history.xlsx"))
+		assert.NotNil(t, err)
+
+		sources[0].Location.TimeZone = ""
+		err = ExportFile(context.Background(), client, sources, exportStart, exportEnd, filepath.Join(dir, "history.csv"), LocalTime())
+		assert.NotNil(t, err)
+
+		sources[1].Point.ID = "missing"
+		path := filepath.Join(dir, "history.parquet")
+		err = ExportFile(context.Background(), client, sources, exportStart, exportEnd, path)
+		assert.True(t, buildingx.IsNotFound(err))
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+}
@@ -0,0 +1,68 @@
+package buildingxexport
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	measurementEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, " ", `\ `)
+	tagEscaper         = strings.NewReplacer(`\`, `\\`, ",", `\,`, "=", `\=`, " ", `\ `)
+	stringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+// LineProtocolWriter writes rows as InfluxDB line protocol, one line per row with nanosecond timestamps. InfluxDB
+// rejects a field whose type differs from earlier writes to the same measurement, so each field has one type:
+// numeric values, including booleans as 1 or 0 and enumerated values as their state number, are written to a
+// float value field, and text values, including values that don't match the data type of their point, to a
+// string text field.
+type LineProtocolWriter struct {
+	writer  *bufio.Writer
+	options encoderOptions
+	line    []byte
+}
+
+// NewLineProtocolWriter returns a writer of InfluxDB line protocol
+func NewLineProtocolWriter(w io.Writer, opts ...EncoderOption) *LineProtocolWriter {
+	return &LineProtocolWriter{writer: bufio.NewWriter(w), options: newEncoderOptions(opts)}
+}
+
+// WriteRow writes the line of a row
+func (w *LineProtocolWriter) WriteRow(row Row) error {
+
+	line := append(w.line[:0], measurementEscaper.Replace(w.options.measurement)...)
+	for _, tag := range w.options.tags(row) {
+		line = append(line, ',')
+		line = append(line, tagEscaper.Replace(tag.key)...)
+		line = append(line, '=')
+		line = append(line, tagEscaper.Replace(tag.value)...)
+	}
+	line = append(line, ' ')
+	line = append(line, lineField(row)...)
+	line = append(line, ' ')
+	line = strconv.AppendInt(line, row.Time.UnixNano(), 10)
+	line = append(line, '\n')
+	w.line = line
+
+	_, err := w.writer.Write(line)
+	return err
+
+}
+
+// Close flushes the lines
+func (w *LineProtocolWriter) Close() error {
+	return w.writer.Flush()
+}
+
+// lineField returns the field holding the value of a row: a float value field for numeric values, and a string
+// text field otherwise
+func lineField(row Row) string {
+
+	if row.Number != nil {
+		return "value=" + formatFloat(*row.Number)
+	}
+	return `text="` + stringEscaper.Replace(row.Value) + `"`
+
+}
@@ -0,0 +1,108 @@
+package buildingxexport
+
+import (
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/snappy"
+)
+
+const (
+	defaultRowGroupSize = 100000
+	parquetBatchSize    = 1024
+)
+
+// ParquetWriter writes rows to a Parquet file. Rows are buffered until a row group is complete, so memory use
+// is bounded by the row group size rather than by the size of the export.
+type ParquetWriter struct {
+	writer *parquet.GenericWriter[parquetRow]
+	batch  []parquetRow
+}
+
+type parquetRow struct {
+	Timestamp int64    `parquet:"timestamp,timestamp(microsecond)"`
+	TimeZone  string   `parquet:"time_zone,dict"`
+	PointID   string   `parquet:"point_id,dict"`
+	PointName string   `parquet:"point_name,dict"`
+	Device    string   `parquet:"device,dict"`
+	Location  string   `parquet:"location,dict"`
+	Units     string   `parquet:"units,dict"`
+	Value     string   `parquet:"value"`
+	Number    *float64 `parquet:"number,optional"`
+}
+
+// ParquetOption changes how a Parquet file is written
+type ParquetOption func(*parquetOptions)
+
+type parquetOptions struct {
+	rowGroupSize int64
+}
+
+// RowGroupSize sets the number of rows of each row group. It defaults to 100,000.
+func RowGroupSize(rows int64) ParquetOption {
+	return func(o *parquetOptions) { o.rowGroupSize = rows }
+}
+
+// NewParquetWriter returns a writer of Parquet rows. Nothing is written until the first row group is
+// complete or the writer is closed.
+func NewParquetWriter(w io.Writer, opts ...ParquetOption) *ParquetWriter {
+
+	o := parquetOptions{rowGroupSize: defaultRowGroupSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.rowGroupSize < 1 {
+		o.rowGroupSize = defaultRowGroupSize
+	}
+
+	return &ParquetWriter{
+		writer: parquet.NewGenericWriter[parquetRow](w,
+			parquet.Compression(&snappy.Codec{}),
+			parquet.MaxRowsPerRowGroup(o.rowGroupSize),
+			parquet.CreatedBy("buildingxexport", "", ""),
+		),
+		batch: make([]parquetRow, 0, parquetBatchSize),
+	}
+
+}
+
+// WriteRow writes a row
+func (w *ParquetWriter) WriteRow(row Row) error {
+
+	w.batch = append(w.batch, parquetRow{
+		Timestamp: row.Time.UnixMicro(),
+		TimeZone:  row.Time.Location().String(),
+		PointID:   row.PointID,
+		PointName: row.PointName,
+		Device:    row.Device,
+		Location:  row.Location,
+		Units:     row.Units,
+		Value:     row.Value,
+		Number:    row.Number,
+	})
+	if len(w.batch) < parquetBatchSize {
+		return nil
+	}
+	return w.flushBatch()
+
+}
+
+// Close writes the last row group and the footer of the file
+func (w *ParquetWriter) Close() error {
+
+	if err := w.flushBatch(); err != nil {
+		return err
+	}
+	return w.writer.Close()
+
+}
+
+func (w *ParquetWriter) flushBatch() error {
+
+	if _, err := w.writer.Write(w.batch); err != nil {
+		return err
+	}
+	w.batch = w.batch[:0]
+	return nil
+
+}
@@ -0,0 +1,179 @@
+package buildingxexport
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// OpenMetricsWriter writes rows as samples of a gauge in the OpenMetrics text format, with timestamps in
+// seconds. Booleans are 1 or 0 and enumerated values are their state number; rows without a numeric value
+// (ex: string points) are left out. Close writes the EOF marker that ends the exposition.
+type OpenMetricsWriter struct {
+	writer  *bufio.Writer
+	options encoderOptions
+	header  bool
+}
+
+// NewOpenMetricsWriter returns a writer of OpenMetrics samples
+func NewOpenMetricsWriter(w io.Writer, opts ...EncoderOption) *OpenMetricsWriter {
+	return &OpenMetricsWriter{writer: bufio.NewWriter(w), options: newEncoderOptions(opts)}
+}
+
+// WriteRow writes the sample of a row, after the metadata of the metric when it is the first one
+func (w *OpenMetricsWriter) WriteRow(row Row) error {
+
+	if row.Number == nil {
+		return nil
+	}
+	w.writeHeader()
+
+	w.writer.WriteString(w.options.metricName)
+	w.writer.WriteByte('{')
+	for i, tag := range w.options.labels(row) {
+		if i > 0 {
+			w.writer.WriteByte(',')
+		}
+		w.writer.WriteString(tag.key + `="` + labelEscaper.Replace(tag.value) + `"`)
+	}
+	w.writer.WriteString("} " + formatFloat(*row.Number) + " ")
+	millis := row.Time.UnixMilli()
+	w.writer.WriteString(strconv.FormatInt(millis/1000, 10) + "." + strconv.FormatInt(1000+millis%1000, 10)[1:])
+	_, err := w.writer.WriteString("\n")
+	return err
+
+}
+
+// Close writes the EOF marker and flushes the samples
+func (w *OpenMetricsWriter) Close() error {
+
+	w.writeHeader()
+	w.writer.WriteString("# EOF\n")
+	return w.writer.Flush()
+
+}
+
+func (w *OpenMetricsWriter) writeHeader() {
+
+	if w.header {
+		return
+	}
+	w.header = true
+	w.writer.WriteString("# TYPE " + w.options.metricName + " gauge\n")
+	w.writer.WriteString("# HELP " + w.options.metricName + " Value of a Building X point\n")
+
+}
+
+// labels returns the tags of the series of a row as Prometheus labels, sorted by name. Label names may only
+// contain letters, digits and underscores and may not start with a digit, so other characters of the constant
+// tag keys are replaced with underscores (ex: site-name becomes site_name) and a leading digit is prefixed
+// with one.
+func (o encoderOptions) labels(row Row) []tag {
+
+	labels := o.tags(row)
+	for i := range labels {
+		labels[i].key = labelName(labels[i].key)
+	}
+	sort.SliceStable(labels, func(i, j int) bool { return labels[i].key < labels[j].key })
+	return labels
+
+}
+
+func labelName(key string) string {
+
+	name := []byte(key)
+	for i, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || name[0] >= '0' && name[0] <= '9' {
+		name = append([]byte{'_'}, name...)
+	}
+	return string(name)
+
+}
+
+// RemoteWriteWriter encodes rows as a Prometheus remote-write request: a WriteRequest protocol buffer
+// compressed with Snappy, ready to be sent with the Content-Encoding: snappy header. Rows are converted as for
+// OpenMetricsWriter and grouped into one time series per point. A request is a single message, so the rows
+// are held until Close writes it; export long histories in several requests.
+type RemoteWriteWriter struct {
+	writer  io.Writer
+	options encoderOptions
+	series  []*remoteSeries
+	index   map[string]*remoteSeries
+}
+
+type remoteSeries struct {
+	labels  []tag
+	samples []byte
+}
+
+// NewRemoteWriteWriter returns a writer of a Prometheus remote-write request
+func NewRemoteWriteWriter(w io.Writer, opts ...EncoderOption) *RemoteWriteWriter {
+	return &RemoteWriteWriter{writer: w, options: newEncoderOptions(opts), index: make(map[string]*remoteSeries)}
+}
+
+// WriteRow adds the sample of a row to the time series of its point
+func (w *RemoteWriteWriter) WriteRow(row Row) error {
+
+	if row.Number == nil {
+		return nil
+	}
+
+	// the metric name is the __name__ label, and receivers expect the labels sorted by name
+	labels := append(w.options.labels(row), tag{key: "__name__", value: w.options.metricName})
+	sort.SliceStable(labels, func(i, j int) bool { return labels[i].key < labels[j].key })
+	key := strings.Builder{}
+	for _, label := range labels {
+		key.WriteString(label.key + "\x00" + label.value + "\x00")
+	}
+	series, ok := w.index[key.String()]
+	if !ok {
+		series = &remoteSeries{labels: labels}
+		w.index[key.String()] = series
+		w.series = append(w.series, series)
+	}
+
+	sample := protowire.AppendTag(nil, 1, protowire.Fixed64Type)
+	sample = protowire.AppendFixed64(sample, math.Float64bits(*row.Number))
+	sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+	sample = protowire.AppendVarint(sample, uint64(row.Time.UnixMilli()))
+	series.samples = protowire.AppendTag(series.samples, 2, protowire.BytesType)
+	series.samples = protowire.AppendBytes(series.samples, sample)
+	return nil
+
+}
+
+// Close writes the compressed request
+func (w *RemoteWriteWriter) Close() error {
+
+	request := make([]byte, 0)
+	for _, series := range w.series {
+		message := make([]byte, 0, len(series.samples)+64)
+		for _, label := range series.labels {
+			pair := protowire.AppendTag(nil, 1, protowire.BytesType)
+			pair = protowire.AppendString(pair, label.key)
+			pair = protowire.AppendTag(pair, 2, protowire.BytesType)
+			pair = protowire.AppendString(pair, label.value)
+			message = protowire.AppendTag(message, 1, protowire.BytesType)
+			message = protowire.AppendBytes(message, pair)
+		}
+		message = append(message, series.samples...)
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, message)
+	}
+
+	_, err := w.writer.Write(snappy.Encode(nil, request))
+	return err
+
+}
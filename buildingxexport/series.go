@@ -0,0 +1,79 @@
+package buildingxexport
+
+import (
+	"sort"
+	"strconv"
+)
+
+const (
+	defaultMeasurement = "buildingx_point"
+	defaultMetricName  = "buildingx_point_value"
+)
+
+// EncoderOption changes how rows are encoded for a time-series database
+type EncoderOption func(*encoderOptions)
+
+type encoderOptions struct {
+	measurement string
+	metricName  string
+	constant    map[string]string
+}
+
+// Measurement sets the InfluxDB measurement of the line protocol. It defaults to buildingx_point.
+func Measurement(name string) EncoderOption {
+	return func(o *encoderOptions) { o.measurement = name }
+}
+
+// MetricName sets the name of the Prometheus metric. It defaults to buildingx_point_value.
+func MetricName(name string) EncoderOption {
+	return func(o *encoderOptions) { o.metricName = name }
+}
+
+// WithTags adds constant tags (labels for Prometheus) to every series (ex: the partition or the site). The
+// Prometheus encoders turn the keys into valid label names.
+func WithTags(tags map[string]string) EncoderOption {
+	return func(o *encoderOptions) { o.constant = tags }
+}
+
+func newEncoderOptions(opts []EncoderOption) encoderOptions {
+
+	o := encoderOptions{measurement: defaultMeasurement, metricName: defaultMetricName}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+
+}
+
+// tag is a tag of a series, called a label by Prometheus
+type tag struct {
+	key   string
+	value string
+}
+
+// tags returns the tags of the series of a row, sorted by key: the location, device and point names, the point
+// ID and the units, along with the constant tags. Empty tags are left out.
+func (o encoderOptions) tags(row Row) []tag {
+
+	tags := make([]tag, 0, 5+len(o.constant))
+	add := func(key, value string) {
+		if value != "" {
+			tags = append(tags, tag{key: key, value: value})
+		}
+	}
+	for key, value := range o.constant {
+		add(key, value)
+	}
+	add("location", row.Location)
+	add("device", row.Device)
+	add("point", row.PointName)
+	add("point_id", row.PointID)
+	add("units", row.Units)
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].key < tags[j].key })
+	return tags
+
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
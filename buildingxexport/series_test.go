@@ -0,0 +1,237 @@
+package buildingxexport
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var sampleTime = time.Date(2022, 5, 1, 12, 0, 0, 500000000, time.UTC)
+
+// sampleRows returns a row of each data type, plus a numeric point with a text value, all from the same
+// device and recorded at sampleTime
+func sampleRows() []Row {
+
+	device := buildingx.Device{Name: "AHU 1"}
+	location := buildingx.Location{Name: "Headquarters"}
+	points := []buildingx.Point{
+		{ID: "point-1", Name: "SupplyFanCmd", DataType: buildingx.DataTypeBoolean, StringValue: "active"},
+		{ID: "point-2", Name: "SupplyAirTemp", DataType: buildingx.DataTypeNumber, StringValue: "55.2", Units: "°F"},
+		{ID: "point-4", Name: "OccupancyMode", DataType: buildingx.DataTypeEnum, StringValue: "3"},
+		{ID: "point-5", Name: "Status", DataType: buildingx.DataTypeString, StringValue: `say "hi"`},
+		{ID: "point-6", Name: "ReturnAirTemp", DataType: buildingx.DataTypeNumber, StringValue: "fault"},
+	}
+	rows := make([]Row, len(points))
+	for i, point := range points {
+		point.Timestamp = sampleTime
+		rows[i] = NewPointRow(Source{Point: point, Device: device, Location: location}, nil)
+	}
+	return rows
+
+}
+
+func writeRows(t *testing.T, w RowWriter, rows []Row) {
+
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal("error writing row: ", err.Error())
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal("error closing writer: ", err.Error())
+	}
+
+}
+
+func TestLineProtocol(t *testing.T) {
+
+	t.Run("data-types", func(t *testing.T) {
+		buffer := bytes.Buffer{}
+		writeRows(t, NewLineProtocolWriter(&buffer, WithTags(map[string]string{"site": "north campus"})), sampleRows())
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		assertFieldTypes(t, lines)
+		assert.Equal(t, []string{
+			`buildingx_point,device=AHU\ 1,location=Headquarters,point=SupplyFanCmd,point_id=point-1,site=north\ campus value=1 1651406400500000000`,
+			`buildingx_point,device=AHU\ 1,location=Headquarters,point=SupplyAirTemp,point_id=point-2,site=north\ campus,units=°F value=55.2 1651406400500000000`,
+			`buildingx_point,device=AHU\ 1,location=Headquarters,point=OccupancyMode,point_id=point-4,site=north\ campus value=3 1651406400500000000`,
+			`buildingx_point,device=AHU\ 1,location=Headquarters,point=Status,point_id=point-5,site=north\ campus text="say \"hi\"" 1651406400500000000`,
+			`buildingx_point,device=AHU\ 1,location=Headquarters,point=ReturnAirTemp,point_id=point-6,site=north\ campus text="fault" 1651406400500000000`,
+		}, lines)
+	})
+	t.Run("data-type-aliases", func(t *testing.T) {
+		rows := make([]Row, 0)
+		for _, point := range []buildingx.Point{
+			{ID: "point-1", DataType: "Boolean", StringValue: "1"},
+			{ID: "point-4", DataType: "multistate", StringValue: "2"},
+			{ID: "point-7", DataType: "enumeration", StringValue: "4"},
+			{ID: "point-8", DataType: "analog", StringValue: "60.5"},
+		} {
+			point.Timestamp = sampleTime
+			rows = append(rows, NewPointRow(Source{Point: point}, nil))
+		}
+		buffer := bytes.Buffer{}
+		writeRows(t, NewLineProtocolWriter(&buffer), rows)
+		assert.Equal(t, []string{
+			`buildingx_point,point_id=point-1 value=1 1651406400500000000`,
+			`buildingx_point,point_id=point-4 value=2 1651406400500000000`,
+			`buildingx_point,point_id=point-7 value=4 1651406400500000000`,
+			`buildingx_point,point_id=point-8 value=60.5 1651406400500000000`,
+		}, strings.Split(strings.TrimSpace(buffer.String()), "\n"))
+	})
+	t.Run("history", func(t *testing.T) {
+		client, sources := newExporter(t)
+		buffer := bytes.Buffer{}
+		w := NewLineProtocolWriter(&buffer, Measurement("trend"))
+		if err := Export(context.Background(), client, sources, exportStart, exportEnd, w); err != nil {
+			t.Fatal("error exporting: ", err.Error())
+		}
+		assert.Nil(t, w.Close())
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		assertFieldTypes(t, lines)
+		assert.Equal(t, 6, len(lines))
+		assert.Equal(t, "trend,device=AHU-1,location=Headquarters,point=SupplyFanCmd,point_id=point-1 value=0 1651401000000000000", lines[1])
+	})
+
+}
+
+// assertFieldTypes asserts that no field of a measurement is written with more than one type, which InfluxDB
+// would reject as a field type conflict
+func assertFieldTypes(t *testing.T, lines []string) {
+
+	types := make(map[string]string)
+	for _, line := range lines {
+		space := 0
+		for space < len(line) && (line[space] != ' ' || line[space-1] == '\\') {
+			space++
+		}
+		measurement := strings.SplitN(line[:space], ",", 2)[0]
+		field := line[space+1 : strings.LastIndex(line, " ")]
+		key, value := field[:strings.Index(field, "=")], field[strings.Index(field, "=")+1:]
+		fieldType := "float"
+		switch {
+		case strings.HasPrefix(value, `"`):
+			fieldType = "string"
+		case strings.HasSuffix(value, "i"):
+			fieldType = "integer"
+		case value == "true" || value == "false":
+			fieldType = "boolean"
+		}
+		if previous, ok := types[measurement+"."+key]; ok {
+			assert.Equal(t, previous, fieldType, "type of field %s of measurement %s", key, measurement)
+		}
+		types[measurement+"."+key] = fieldType
+	}
+
+}
+
+func TestOpenMetrics(t *testing.T) {
+
+	buffer := bytes.Buffer{}
+	writeRows(t, NewOpenMetricsWriter(&buffer), sampleRows()[:4])
+	assert.Equal(t, `# TYPE buildingx_point_value gauge
+# HELP buildingx_point_value Value of a Building X point
+buildingx_point_value{device="AHU 1",location="Headquarters",point="SupplyFanCmd",point_id="point-1"} 1 1651406400.500
+buildingx_point_value{device="AHU 1",location="Headquarters",point="SupplyAirTemp",point_id="point-2",units="°F"} 55.2 1651406400.500
+buildingx_point_value{device="AHU 1",location="Headquarters",point="OccupancyMode",point_id="point-4"} 3 1651406400.500
+# EOF
+`, buffer.String())
+
+	buffer.Reset()
+	writeRows(t, NewOpenMetricsWriter(&buffer, MetricName("bx")), nil)
+	assert.Equal(t, "# TYPE bx gauge\n# HELP bx Value of a Building X point\n# EOF\n", buffer.String())
+
+	buffer.Reset()
+	writeRows(t, NewOpenMetricsWriter(&buffer, WithTags(map[string]string{"site-name": "north", "1zone": "a"})), sampleRows()[:1])
+	assert.Contains(t, buffer.String(), `buildingx_point_value{_1zone="a",device="AHU 1",location="Headquarters",point="SupplyFanCmd",point_id="point-1",site_name="north"} 1 `)
+
+}
+
+// remoteSample is a decoded sample of a remote-write time series
+type remoteSample struct {
+	value  float64
+	millis int64
+}
+
+func TestRemoteWrite(t *testing.T) {
+
+	rows := sampleRows()
+	later := rows[1]
+	later.Time = later.Time.Add(time.Minute)
+	buffer := bytes.Buffer{}
+	writeRows(t, NewRemoteWriteWriter(&buffer), append(rows, later))
+
+	labels, samples := decodeRemoteWrite(t, buffer.Bytes())
+	assert.Equal(t, 3, len(labels))
+	assert.Equal(t, []string{"__name__=buildingx_point_value", "device=AHU 1", "location=Headquarters", "point=SupplyAirTemp", "point_id=point-2", "units=°F"}, labels[1])
+	assert.Equal(t, []remoteSample{{55.2, 1651406400500}, {55.2, 1651406460500}}, samples[1])
+	assert.Equal(t, []remoteSample{{3, 1651406400500}}, samples[2])
+
+	// labels are sorted by name, including __name__, and tag keys are made valid label names
+	buffer.Reset()
+	tags := map[string]string{"Site": "north", "site-name": "campus", "1zone": "a"}
+	writeRows(t, NewRemoteWriteWriter(&buffer, WithTags(tags)), rows[:1])
+	labels, _ = decodeRemoteWrite(t, buffer.Bytes())
+	assert.Equal(t, [][]string{{"Site=north", "_1zone=a", "__name__=buildingx_point_value", "device=AHU 1", "location=Headquarters", "point=SupplyFanCmd", "point_id=point-1", "site_name=campus"}}, labels)
+
+}
+
+// decodeRemoteWrite returns the labels and the samples of each time series of a remote-write request
+func decodeRemoteWrite(t *testing.T, data []byte) ([][]string, [][]remoteSample) {
+
+	request, err := snappy.Decode(nil, data)
+	if err != nil {
+		t.Fatal("error decoding request: ", err.Error())
+	}
+	labels := make([][]string, 0)
+	samples := make([][]remoteSample, 0)
+	for _, series := range fields(t, request, 1) {
+		pairs := make([]string, 0)
+		for _, label := range fields(t, series, 1) {
+			pairs = append(pairs, string(fields(t, label, 1)[0])+"="+string(fields(t, label, 2)[0]))
+		}
+		labels = append(labels, pairs)
+		decoded := make([]remoteSample, 0)
+		for _, sample := range fields(t, series, 2) {
+			value, n := protowire.ConsumeFixed64(sample[1:])
+			millis, _ := protowire.ConsumeVarint(sample[1+n+1:])
+			decoded = append(decoded, remoteSample{value: math.Float64frombits(value), millis: int64(millis)})
+		}
+		samples = append(samples, decoded)
+	}
+	return labels, samples
+
+}
+
+// fields returns the values of the length-delimited fields of a message with the given number
+func fields(t *testing.T, message []byte, number protowire.Number) [][]byte {
+
+	values := make([][]byte, 0)
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			t.Fatal("error decoding tag: ", protowire.ParseError(n).Error())
+		}
+		message = message[n:]
+		if typ == protowire.BytesType && num == number {
+			value, m := protowire.ConsumeBytes(message)
+			values = append(values, value)
+			message = message[m:]
+			continue
+		}
+		m := protowire.ConsumeFieldValue(num, typ, message)
+		if m < 0 {
+			t.Fatal("error decoding field: ", protowire.ParseError(m).Error())
+		}
+		message = message[m:]
+	}
+	return values
+
+}
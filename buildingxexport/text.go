@@ -0,0 +1,113 @@
+package buildingxexport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// CSVWriter writes rows as CSV with a header line. Cells without a value (ex: the number of a text value) are
+// empty.
+type CSVWriter struct {
+	writer *csv.Writer
+	header bool
+	row    []string
+}
+
+// NewCSVWriter returns a writer of CSV rows
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{writer: csv.NewWriter(w), row: make([]string, len(Columns))}
+}
+
+// WriteRow writes a row, after the header when it is the first one
+func (w *CSVWriter) WriteRow(row Row) error {
+
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.row[0] = timestamp(row.Time)
+	w.row[1] = row.Time.Location().String()
+	w.row[2] = row.PointID
+	w.row[3] = row.PointName
+	w.row[4] = row.Device
+	w.row[5] = row.Location
+	w.row[6] = row.Units
+	w.row[7] = row.Value
+	w.row[8] = ""
+	if row.Number != nil {
+		w.row[8] = strconv.FormatFloat(*row.Number, 'f', -1, 64)
+	}
+	return w.writer.Write(w.row)
+
+}
+
+// Close writes the header if no row was written and flushes the rows
+func (w *CSVWriter) Close() error {
+
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+
+}
+
+func (w *CSVWriter) writeHeader() error {
+
+	if w.header {
+		return nil
+	}
+	w.header = true
+	return w.writer.Write(Columns)
+
+}
+
+// JSONLinesWriter writes a JSON object per row and per line
+type JSONLinesWriter struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+type jsonRow struct {
+	Timestamp string   `json:"timestamp"`
+	TimeZone  string   `json:"time_zone"`
+	PointID   string   `json:"point_id"`
+	PointName string   `json:"point_name"`
+	Device    string   `json:"device"`
+	Location  string   `json:"location"`
+	Units     string   `json:"units"`
+	Value     string   `json:"value"`
+	Number    *float64 `json:"number"`
+}
+
+// NewJSONLinesWriter returns a writer of JSON Lines rows
+func NewJSONLinesWriter(w io.Writer) *JSONLinesWriter {
+
+	writer := bufio.NewWriter(w)
+	return &JSONLinesWriter{writer: writer, encoder: json.NewEncoder(writer)}
+
+}
+
+// WriteRow writes a row
+func (w *JSONLinesWriter) WriteRow(row Row) error {
+
+	return w.encoder.Encode(jsonRow{
+		Timestamp: timestamp(row.Time),
+		TimeZone:  row.Time.Location().String(),
+		PointID:   row.PointID,
+		PointName: row.PointName,
+		Device:    row.Device,
+		Location:  row.Location,
+		Units:     row.Units,
+		Value:     row.Value,
+		Number:    row.Number,
+	})
+
+}
+
+// Close flushes the rows
+func (w *JSONLinesWriter) Close() error {
+	return w.writer.Flush()
+}
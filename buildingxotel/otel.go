@@ -0,0 +1,132 @@
+// Package buildingxotel integrates the Building X Operations API library with OpenTelemetry. It emits a span
+// for every call made to the Operations API and the token endpoint and records request counts, latency,
+// errors by status and token refreshes, all tagged with the operation and partition.
+//
+// It lives in its own module so that projects which do not use OpenTelemetry do not depend on it.
+package buildingxotel
+
+import (
+	"context"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/cloudlinesolutions/buildingx-operations-api/buildingxotel"
+
+// Option configures the instrumentation
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider sets the tracer provider. The global provider is used by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) { c.tracerProvider = provider }
+}
+
+// WithMeterProvider sets the meter provider. The global provider is used by default.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) { c.meterProvider = provider }
+}
+
+// Instrumentation implements buildingx.Instrumentation with OpenTelemetry
+type Instrumentation struct {
+	tracer trace.Tracer
+
+	requests  metric.Int64Counter
+	duration  metric.Float64Histogram
+	errors    metric.Int64Counter
+	refreshes metric.Int64Counter
+}
+
+// New creates the instrumentation. Pass it to a client with buildingx.WithInstrumentation.
+func New(opts ...Option) (*Instrumentation, error) {
+
+	c := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	meter := c.meterProvider.Meter(instrumentationName)
+	i := &Instrumentation{tracer: c.tracerProvider.Tracer(instrumentationName)}
+
+	var err error
+	if i.requests, err = meter.Int64Counter("buildingx.requests",
+		metric.WithDescription("Number of calls made to Building X")); err != nil {
+		return nil, err
+	}
+	if i.duration, err = meter.Float64Histogram("buildingx.request.duration",
+		metric.WithDescription("Duration of calls made to Building X"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if i.errors, err = meter.Int64Counter("buildingx.errors",
+		metric.WithDescription("Number of calls to Building X that failed, by status")); err != nil {
+		return nil, err
+	}
+	if i.refreshes, err = meter.Int64Counter("buildingx.token.refreshes",
+		metric.WithDescription("Number of token refreshes, including failed ones")); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+
+}
+
+// StartCall starts a client span for the call and records its metrics once it completes
+func (i *Instrumentation) StartCall(ctx context.Context, event buildingx.CallEvent) (context.Context, func(buildingx.CallEvent)) {
+
+	ctx, span := i.tracer.Start(ctx, event.Operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("buildingx.operation", event.Operation),
+			attribute.String("buildingx.partition", event.Partition),
+			attribute.String("buildingx.path", event.Path),
+			attribute.String("http.method", event.Method),
+		))
+
+	return ctx, func(event buildingx.CallEvent) {
+
+		attrs := metric.WithAttributes(
+			attribute.String("operation", event.Operation),
+			attribute.String("partition", event.Partition),
+		)
+		i.requests.Add(ctx, 1, attrs)
+		i.duration.Record(ctx, event.Duration.Seconds(), attrs)
+
+		if event.StatusCode != 0 {
+			span.SetAttributes(attribute.Int("http.status_code", event.StatusCode))
+		}
+		if event.Err != nil {
+			span.RecordError(event.Err)
+			span.SetStatus(codes.Error, event.Err.Error())
+			i.errors.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("operation", event.Operation),
+				attribute.String("partition", event.Partition),
+				attribute.Int("status", event.StatusCode),
+			))
+		}
+		span.End()
+
+	}
+
+}
+
+// TokenRefreshed counts the refresh
+func (i *Instrumentation) TokenRefreshed(ctx context.Context, partition string, err error) {
+
+	i.refreshes.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("partition", partition),
+		attribute.Bool("success", err == nil),
+	))
+
+}
@@ -0,0 +1,87 @@
+package buildingxotel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+	"github.com/stretchr/testify/assert"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstrumentation(t *testing.T) {
+
+	authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"access_token":"token","expires_in":3600,"token_type":"Bearer"}`)
+	}))
+	defer authServer.Close()
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/operations/partitions/partition/points/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"id":"point-1"}}`)
+	}))
+	defer apiServer.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	instrumentation, err := New(
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatal("error creating instrumentation: ", err.Error())
+	}
+
+	client, err := buildingx.NewClient(buildingx.Config{
+		Endpoint:     apiServer.URL,
+		AuthURL:      authServer.URL,
+		Audience:     "audience",
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Partition:    "partition",
+	}, buildingx.WithInstrumentation(instrumentation))
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+
+	_, err = client.GetSinglePoint("point-1")
+	assert.Nil(t, err)
+	_, err = client.GetSinglePoint("missing")
+	assert.NotNil(t, err)
+
+	t.Run("spans", func(t *testing.T) {
+		names := make([]string, 0)
+		for _, span := range spans.Ended() {
+			names = append(names, span.Name())
+		}
+		assert.Equal(t, []string{"GetToken", "GetSinglePoint", "GetSinglePoint"}, names)
+	})
+	t.Run("metrics", func(t *testing.T) {
+		data := metricdata.ResourceMetrics{}
+		if err := reader.Collect(context.Background(), &data); err != nil {
+			t.Fatal("error collecting metrics: ", err.Error())
+		}
+		totals := make(map[string]int64)
+		for _, scope := range data.ScopeMetrics {
+			for _, m := range scope.Metrics {
+				if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+					for _, point := range sum.DataPoints {
+						totals[m.Name] += point.Value
+					}
+				}
+			}
+		}
+		assert.Equal(t, int64(3), totals["buildingx.requests"])
+		assert.Equal(t, int64(1), totals["buildingx.errors"])
+		assert.Equal(t, int64(1), totals["buildingx.token.refreshes"])
+	})
+
+}
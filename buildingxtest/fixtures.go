@@ -0,0 +1,175 @@
+package buildingxtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+)
+
+// Fixtures is the data served by a fake Server. It is usually built in code or loaded from a JSON file
+// with LoadFixtures.
+type Fixtures struct {
+	Locations []buildingx.Location `json:"locations"`
+	Devices   []Device             `json:"devices"`
+	Points    []Point              `json:"points"`
+	// History holds the recorded values of each point, keyed by point ID
+	History map[string][]buildingx.PointHistory `json:"history"`
+}
+
+// Device is a device fixture along with the location it is installed at and the gateway it is connected to
+type Device struct {
+	buildingx.Device
+	LocationID string `json:"locationId"`
+	// GatewayID is empty for the gateways themselves
+	GatewayID string `json:"gatewayId"`
+}
+
+// Point is a point fixture along with the device it resides on
+type Point struct {
+	buildingx.Point
+	DeviceID string `json:"deviceId"`
+}
+
+// LoadFixtures reads fixtures from a JSON file
+func LoadFixtures(path string) (Fixtures, error) {
+
+	fixtures := Fixtures{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fixtures, fmt.Errorf("unable to read fixtures: %w", err)
+	}
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return fixtures, fmt.Errorf("unable to parse fixtures %s: %w", path, err)
+	}
+	return fixtures, nil
+
+}
+
+// DefaultFixtures returns a small site: one building with an X300 gateway, a PXC4 controller connected to it
+// and a few points on the controller (a fan command, a temperature, a setpoint with limits and an occupancy
+// mode), one of which has history
+func DefaultFixtures() Fixtures {
+
+	updated := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	return Fixtures{
+		Locations: []buildingx.Location{
+			{
+				ID:          "location-1",
+				Name:        "Headquarters",
+				Description: "Main office building",
+				Street:      "100 Main Street",
+				City:        "Chicago",
+				PostalCode:  "60601",
+				Country:     "US",
+				TimeZone:    "America/Chicago",
+			},
+		},
+		Devices: []Device{
+			{
+				Device: buildingx.Device{
+					ID:           "gateway-1",
+					Name:         "Gateway",
+					Description:  "Connect X300",
+					Model:        "X300",
+					Serial:       "X300-0001",
+					OnlineStatus: "online",
+				},
+				LocationID: "location-1",
+			},
+			{
+				Device: buildingx.Device{
+					ID:           "device-1",
+					Name:         "AHU-1",
+					Description:  "Air handling unit controller",
+					Model:        "PXC4",
+					Serial:       "PXC4-0001",
+					OnlineStatus: "online",
+				},
+				LocationID: "location-1",
+				GatewayID:  "gateway-1",
+			},
+		},
+		Points: []Point{
+			{
+				Point: buildingx.Point{
+					ID:              "point-1",
+					Name:            "SupplyFanCmd",
+					Description:     "Supply fan command",
+					DataType:        "boolean",
+					Writable:        true,
+					Status:          "ok",
+					StringValue:     "false",
+					Timestamp:       updated,
+					StateTexts:      []string{"Off", "On"},
+					BACnetReference: "2098177/binaryValue:1",
+				},
+				DeviceID: "device-1",
+			},
+			{
+				Point: buildingx.Point{
+					ID:              "point-2",
+					Name:            "SupplyAirTemp",
+					Description:     "Supply air temperature",
+					DataType:        "number",
+					Status:          "ok",
+					StringValue:     "55.2",
+					Timestamp:       updated,
+					Units:           "°F",
+					Resolution:      float(0.1),
+					BACnetReference: "2098177/analogInput:2",
+				},
+				DeviceID: "device-1",
+			},
+			{
+				Point: buildingx.Point{
+					ID:              "point-3",
+					Name:            "RoomTempSetpoint",
+					Description:     "Room temperature setpoint",
+					DataType:        "number",
+					Writable:        true,
+					Status:          "ok",
+					StringValue:     "72",
+					Timestamp:       updated,
+					Units:           "°F",
+					Min:             float(55),
+					Max:             float(85),
+					Resolution:      float(0.5),
+					BACnetReference: "2098177/analogValue:3",
+				},
+				DeviceID: "device-1",
+			},
+			{
+				Point: buildingx.Point{
+					ID:              "point-4",
+					Name:            "OccupancyMode",
+					Description:     "Occupancy mode",
+					DataType:        "enum",
+					Writable:        true,
+					Status:          "ok",
+					StringValue:     "1",
+					Timestamp:       updated,
+					StateTexts:      []string{"Occupied", "Unoccupied", "Standby"},
+					BACnetReference: "2098177/multiStateValue:4",
+				},
+				DeviceID: "device-1",
+			},
+		},
+		History: map[string][]buildingx.PointHistory{
+			"point-2": {
+				{Value: "54.8", Timestamp: updated.Add(-3 * time.Hour).Format(time.RFC3339)},
+				{Value: "55.0", Timestamp: updated.Add(-2 * time.Hour).Format(time.RFC3339)},
+				{Value: "55.1", Timestamp: updated.Add(-1 * time.Hour).Format(time.RFC3339)},
+				{Value: "55.2", Timestamp: updated.Format(time.RFC3339)},
+			},
+		},
+	}
+
+}
+
+func float(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,425 @@
+package buildingxtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+)
+
+// Redacted replaces the value of every secret written by a Recorder
+const Redacted = "REDACTED"
+
+// ErrNoInteraction is returned by a Replayer when no recorded interaction matches a request
+var ErrNoInteraction = errors.New("no recorded interaction matches the request")
+
+var (
+	defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+	defaultRedactedFields  = []string{"access_token", "refresh_token", "id_token", "client_id", "client_secret", "password"}
+)
+
+// Interaction is a request and the response it received, as written by a Recorder
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the recorded form of an http.Request
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the recorded form of an http.Response
+type RecordedResponse struct {
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// RecordOption configures a Recorder
+type RecordOption func(*Recorder)
+
+// RedactHeaders adds headers whose values are redacted. Authorization, Proxy-Authorization, Cookie and
+// Set-Cookie are always redacted.
+func RedactHeaders(names ...string) RecordOption {
+	return func(r *Recorder) {
+		for _, name := range names {
+			r.headers[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// RedactFields adds JSON properties whose values are redacted wherever they appear in a request or response
+// body. The OAuth tokens and client credentials are always redacted.
+func RedactFields(names ...string) RecordOption {
+	return func(r *Recorder) {
+		for _, name := range names {
+			r.fields[name] = true
+		}
+	}
+}
+
+// Recorder writes every request made through its middleware, along with the response, to a writer as JSON
+// lines. Credentials and tokens are redacted before anything is written.
+type Recorder struct {
+	headers map[string]bool
+	fields  map[string]bool
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder returns a recorder that writes to w
+func NewRecorder(w io.Writer, opts ...RecordOption) *Recorder {
+
+	r := &Recorder{
+		headers: make(map[string]bool),
+		fields:  make(map[string]bool),
+		enc:     json.NewEncoder(w),
+	}
+	RedactHeaders(defaultRedactedHeaders...)(r)
+	RedactFields(defaultRedactedFields...)(r)
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+
+}
+
+// Middleware returns the middleware that records traffic, to be installed with buildingx.WithMiddleware
+func (r *Recorder) Middleware() buildingx.Middleware {
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return buildingx.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+
+			var requestBody []byte
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := ioutil.ReadAll(req.Body)
+				req.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+				requestBody = body
+				// requests must not be modified by a round tripper, so send a copy with the body that was read
+				req = req.Clone(req.Context())
+				req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return resp, err
+			}
+			responseBody, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+			if err != nil {
+				return resp, err
+			}
+
+			r.write(Interaction{
+				Request: RecordedRequest{
+					Method: req.Method,
+					URL:    req.URL.String(),
+					Header: r.redactHeader(req.Header),
+					Body:   r.redactBody(requestBody),
+				},
+				Response: RecordedResponse{
+					StatusCode: resp.StatusCode,
+					Header:     r.redactHeader(resp.Header),
+					Body:       r.redactBody(responseBody),
+				},
+			})
+			return resp, nil
+
+		})
+	}
+
+}
+
+// Err returns the first error that occurred while writing an interaction. Recording errors never fail
+// the calls being recorded.
+func (r *Recorder) Err() error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+
+}
+
+func (r *Recorder) write(interaction Interaction) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(interaction); err != nil && r.err == nil {
+		r.err = fmt.Errorf("unable to record interaction: %w", err)
+	}
+
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+
+	redacted := header.Clone()
+	for name := range redacted {
+		if r.headers[http.CanonicalHeaderKey(name)] {
+			redacted[name] = []string{Redacted}
+		}
+	}
+	return redacted
+
+}
+
+// redactBody redacts the secret properties of a JSON body. Bodies that are not JSON are written as they are.
+func (r *Recorder) redactBody(body []byte) string {
+
+	var document interface{}
+	if len(body) == 0 || json.Unmarshal(body, &document) != nil {
+		return string(body)
+	}
+	if !r.redactValue(document) {
+		return string(body)
+	}
+	redacted, err := json.Marshal(document)
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+
+}
+
+// redactValue redacts the secret properties found anywhere in a decoded JSON value and reports whether any were found
+func (r *Recorder) redactValue(value interface{}) bool {
+
+	found := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if r.fields[key] {
+				v[key] = Redacted
+				found = true
+				continue
+			}
+			found = r.redactValue(child) || found
+		}
+	case []interface{}:
+		for _, child := range v {
+			found = r.redactValue(child) || found
+		}
+	}
+	return found
+
+}
+
+// ReadInteractions reads the JSON lines written by a Recorder
+func ReadInteractions(reader io.Reader) ([]Interaction, error) {
+
+	interactions := make([]Interaction, 0)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		interaction := Interaction{}
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return interactions, fmt.Errorf("unable to parse interaction on line %d: %w", line, err)
+		}
+		interactions = append(interactions, interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return interactions, fmt.Errorf("unable to read interactions: %w", err)
+	}
+	return interactions, nil
+
+}
+
+// LoadInteractions reads the interactions recorded to a file
+func LoadInteractions(path string) ([]Interaction, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read interactions: %w", err)
+	}
+	defer file.Close()
+	return ReadInteractions(file)
+
+}
+
+// MatchOn selects the parts of a request that must equal those of a recorded request for its response to be
+// replayed. The scheme and host are never compared, so a recording can be replayed against any endpoint.
+type MatchOn struct {
+	Method bool
+	Path   bool
+	// Query compares the query parameters regardless of their order
+	Query bool
+	Body  bool
+	// Headers lists request headers whose values must be equal. Redacted headers such as Authorization
+	// should not be listed.
+	Headers []string
+}
+
+// DefaultMatch matches on the method, path and query, and ignores headers (including the authorization) and the body
+func DefaultMatch() MatchOn {
+	return MatchOn{Method: true, Path: true, Query: true}
+}
+
+// ReplayOption configures a Replayer
+type ReplayOption func(*Replayer)
+
+// WithMatch sets the parts of a request that are matched. DefaultMatch is used otherwise.
+func WithMatch(match MatchOn) ReplayOption {
+	return func(r *Replayer) { r.match = match.matches }
+}
+
+// WithMatchFunc sets a function that decides whether a recorded request matches a request being made
+func WithMatchFunc(match func(recorded RecordedRequest, req *http.Request, body []byte) bool) ReplayOption {
+	return func(r *Replayer) { r.match = match }
+}
+
+// WithRepeat lets a Replayer serve an interaction again once every matching interaction has been used.
+// By default each recorded interaction is replayed once.
+func WithRepeat() ReplayOption {
+	return func(r *Replayer) { r.repeat = true }
+}
+
+// Replayer is an http.RoundTripper that serves recorded responses instead of making requests. Interactions
+// are used in the order they were recorded, so a session is replayed deterministically. Install it with
+// buildingx.WithTransport.
+type Replayer struct {
+	match  func(recorded RecordedRequest, req *http.Request, body []byte) bool
+	repeat bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer returns a replayer that serves the given interactions
+func NewReplayer(interactions []Interaction, opts ...ReplayOption) *Replayer {
+
+	r := &Replayer{
+		match:        DefaultMatch().matches,
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+
+}
+
+// RoundTrip serves the response of the first unused interaction that matches req. ErrNoInteraction is
+// returned when there is none.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := -1
+	for i, interaction := range r.interactions {
+		if !r.match(interaction.Request, req, body) {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return interaction.Response.response(req), nil
+		}
+		last = i
+	}
+	if r.repeat && last >= 0 {
+		return r.interactions[last].Response.response(req), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL.RequestURI())
+
+}
+
+// Remaining returns the number of interactions that have not been replayed
+func (r *Replayer) Remaining() int {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	remaining := 0
+	for _, used := range r.used {
+		if !used {
+			remaining++
+		}
+	}
+	return remaining
+
+}
+
+func (m MatchOn) matches(recorded RecordedRequest, req *http.Request, body []byte) bool {
+
+	if m.Method && !strings.EqualFold(recorded.Method, req.Method) {
+		return false
+	}
+	recordedURL, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	if m.Path && recordedURL.Path != req.URL.Path {
+		return false
+	}
+	if m.Query && !reflect.DeepEqual(recordedURL.Query(), req.URL.Query()) {
+		return false
+	}
+	if m.Body && recorded.Body != string(body) {
+		return false
+	}
+	for _, name := range m.Headers {
+		if recorded.Header.Get(name) != req.Header.Get(name) {
+			return false
+		}
+	}
+	return true
+
+}
+
+func (r RecordedResponse) response(req *http.Request) *http.Response {
+
+	// the recorded length no longer applies once a body has been redacted
+	header := r.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Del("Content-Length")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+
+}
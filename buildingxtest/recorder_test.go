@@ -0,0 +1,138 @@
+package buildingxtest
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+	"github.com/stretchr/testify/assert"
+)
+
+// record runs fn with a client of a fake server whose traffic is recorded, and returns the recording
+func record(t *testing.T, fn func(client *buildingx.Client)) []byte {
+
+	server := NewServer(DefaultFixtures())
+	defer server.Close()
+
+	recording := bytes.Buffer{}
+	recorder := NewRecorder(&recording, RedactHeaders("X-Api-Key"))
+	client, err := server.Client(buildingx.WithMiddleware(
+		buildingx.HeaderMiddleware(http.Header{"X-Api-Key": []string{"api-key"}}),
+		recorder.Middleware(),
+	))
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+	fn(client)
+	if err := recorder.Err(); err != nil {
+		t.Fatal("error recording: ", err.Error())
+	}
+	return recording.Bytes()
+
+}
+
+// replayConfig returns a configuration for an endpoint that does not exist, which only works with a replayer
+func replayConfig() buildingx.Config {
+
+	return buildingx.Config{
+		Endpoint:     "https://buildingx.invalid",
+		AuthURL:      "https://buildingx.invalid/" + TokenPath,
+		Audience:     "audience",
+		ClientID:     DefaultClientID,
+		ClientSecret: DefaultClientSecret,
+		Partition:    DefaultPartition,
+	}
+
+}
+
+func TestRecordAndReplay(t *testing.T) {
+
+	recording := record(t, func(client *buildingx.Client) {
+		if _, err := client.GetLocations(); err != nil {
+			t.Fatal("error getting locations: ", err.Error())
+		}
+		point, err := client.GetSinglePoint("point-1")
+		if err != nil {
+			t.Fatal("error getting point: ", err.Error())
+		}
+		if err := client.CommandPointValue(&point, "true"); err != nil {
+			t.Fatal("error commanding point: ", err.Error())
+		}
+		if _, err := client.GetSinglePoint("missing"); err == nil {
+			t.Fatal("expected an error getting a missing point")
+		}
+	})
+
+	t.Run("secrets-are-redacted", func(t *testing.T) {
+		for _, secret := range []string{"token-1", DefaultClientSecret, DefaultClientID, "api-key"} {
+			assert.False(t, bytes.Contains(recording, []byte(secret)), secret)
+		}
+		interactions, err := ReadInteractions(bytes.NewReader(recording))
+		if err != nil {
+			t.Fatal("error reading interactions: ", err.Error())
+		}
+		assert.Equal(t, 5, len(interactions))
+		assert.Equal(t, Redacted, interactions[1].Request.Header.Get("Authorization"))
+		assert.True(t, strings.Contains(interactions[0].Response.Body, `"access_token":"REDACTED"`))
+	})
+	t.Run("replay-without-a-server", func(t *testing.T) {
+		interactions, err := ReadInteractions(bytes.NewReader(recording))
+		if err != nil {
+			t.Fatal("error reading interactions: ", err.Error())
+		}
+		replayer := NewReplayer(interactions)
+		client, err := buildingx.NewClient(replayConfig(), buildingx.WithTransport(replayer))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		locations, err := client.GetLocations()
+		if err != nil {
+			t.Fatal("error getting locations: ", err.Error())
+		}
+		assert.Equal(t, "Headquarters", locations[0].Name)
+		point, err := client.GetSinglePoint("point-1")
+		if err != nil {
+			t.Fatal("error getting point: ", err.Error())
+		}
+		assert.Nil(t, client.CommandPointValue(&point, "true"))
+		_, err = client.GetSinglePoint("missing")
+		assert.True(t, buildingx.IsNotFound(err))
+		assert.Equal(t, 0, replayer.Remaining())
+
+		// every interaction has been used
+		_, err = client.GetLocations()
+		assert.True(t, errors.Is(err, ErrNoInteraction))
+	})
+	t.Run("match-options", func(t *testing.T) {
+		interactions, err := ReadInteractions(bytes.NewReader(recording))
+		if err != nil {
+			t.Fatal("error reading interactions: ", err.Error())
+		}
+		noQuery := DefaultMatch()
+		noQuery.Query = false
+		client, err := buildingx.NewClient(replayConfig(), buildingx.WithTransport(NewReplayer(interactions, WithMatch(noQuery), WithRepeat())))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		// the single recorded locations interaction is served again
+		_, err = client.GetLocations()
+		assert.Nil(t, err)
+		_, err = client.GetLocations()
+		assert.Nil(t, err)
+
+		body := DefaultMatch()
+		body.Body = true
+		client, err = buildingx.NewClient(replayConfig(), buildingx.WithTransport(NewReplayer(interactions, WithMatch(body))))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+		err = client.CommandPointValue(&buildingx.Point{ID: "point-1", Writable: true}, "false")
+		assert.True(t, errors.Is(err, ErrNoInteraction))
+	})
+
+}
@@ -0,0 +1,889 @@
+// Package buildingxtest provides an in-process fake of the Building X OAuth token endpoint and Operations API
+// so that code using the buildingx package can be tested offline. The fake is seeded from fixtures and can
+// inject errors, latency and pagination.
+package buildingxtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+)
+
+const (
+	// DefaultPartition is the partition served by a Server unless WithPartition is used
+	DefaultPartition = "partition"
+	// DefaultClientID and DefaultClientSecret are the credentials accepted by a Server unless WithCredentials is used
+	DefaultClientID     = "client-id"
+	DefaultClientSecret = "client-secret"
+
+	// TokenPath is the path of the fake token endpoint. It is also the path matched by a Fault for token requests.
+	TokenPath = "oauth/token"
+
+	apiPrefix = "/operations/partitions/"
+)
+
+// Fault describes an error or delay injected into the responses of a Server
+type Fault struct {
+	// Method is the request method the fault applies to. Empty matches every method.
+	Method string
+	// Path is matched against the start of the request path relative to the partition (ex: "points/point-1"
+	// or "devices"). Token requests have the path TokenPath. Empty matches every request.
+	Path string
+	// Status is the status code to respond with. Zero leaves the response alone, which is useful with Latency.
+	Status int
+	// Errors are returned as the JSON:API errors of the response
+	Errors []buildingx.SBErrorResponse
+	// RetryAfter is sent as the Retry-After header when it is not zero
+	RetryAfter time.Duration
+	// Latency delays the response
+	Latency time.Duration
+	// Disconnect closes the connection without responding, which the client sees as a network error
+	Disconnect bool
+	// Times is the number of requests the fault applies to. Zero applies it until ClearFaults is called.
+	Times int
+}
+
+// Request is a request received by a Server
+type Request struct {
+	Method string
+	// Path is relative to the partition and includes the query (ex: "points/point-1?field[Point]=pointValue").
+	// Token requests have the path TokenPath.
+	Path string
+}
+
+// Command is a point command received by a Server
+type Command struct {
+	PointID string
+	Value   string
+	// Priority is the BACnet priority of the command. Commands sent without a priority are made at 16.
+	Priority int
+	// Release is true when the command relinquishes the priority rather than setting a value
+	Release bool
+	Time    time.Time
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithPartition sets the partition served by the fake. Requests for other partitions are forbidden.
+func WithPartition(partition string) Option {
+	return func(s *Server) { s.partition = partition }
+}
+
+// WithCredentials sets the client id and secret accepted by the fake token endpoint
+func WithCredentials(clientID, clientSecret string) Option {
+	return func(s *Server) {
+		s.clientID = clientID
+		s.clientSecret = clientSecret
+	}
+}
+
+// WithPageSize splits collections into pages of the given size, linked with JSON:API links.next.
+// Zero, the default, returns every item in a single page.
+func WithPageSize(size int) Option {
+	return func(s *Server) { s.pageSize = size }
+}
+
+// WithLatency delays every response
+func WithLatency(latency time.Duration) Option {
+	return func(s *Server) { s.latency = latency }
+}
+
+// WithTokenLifetime sets the lifetime of the tokens issued by the fake. It defaults to one hour.
+func WithTokenLifetime(lifetime time.Duration) Option {
+	return func(s *Server) { s.tokenLifetime = lifetime }
+}
+
+// Server is a fake of the Building X token endpoint and Operations API
+type Server struct {
+	// URL is the base URL of the fake, which is the Endpoint of a client configuration
+	URL string
+	// AuthURL is the URL of the fake token endpoint
+	AuthURL string
+
+	server        *httptest.Server
+	partition     string
+	clientID      string
+	clientSecret  string
+	tokenLifetime time.Duration
+
+	mu         sync.Mutex
+	fixtures   Fixtures
+	relinquish map[string]string
+	pageSize   int
+	latency    time.Duration
+	faults     []*Fault
+	tokens     map[string]time.Time
+	tokenCount int
+	requests   []Request
+	commands   []Command
+}
+
+// NewServer starts a fake serving the given fixtures. The caller must call Close when finished.
+func NewServer(fixtures Fixtures, opts ...Option) *Server {
+
+	s := &Server{
+		partition:     DefaultPartition,
+		clientID:      DefaultClientID,
+		clientSecret:  DefaultClientSecret,
+		tokenLifetime: time.Hour,
+		fixtures:      copyFixtures(fixtures),
+		relinquish:    make(map[string]string),
+		tokens:        make(map[string]time.Time),
+	}
+	// the fixture value of a point is its relinquish default, which it returns to once every command is released
+	for _, point := range s.fixtures.Points {
+		s.relinquish[point.ID] = point.StringValue
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	s.AuthURL = s.server.URL + "/" + TokenPath
+	return s
+
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Config returns a client configuration that uses the fake
+func (s *Server) Config() buildingx.Config {
+
+	return buildingx.Config{
+		Endpoint:     s.URL,
+		AuthURL:      s.AuthURL,
+		Audience:     "https://horizon.siemens.com",
+		ClientID:     s.clientID,
+		ClientSecret: s.clientSecret,
+		Partition:    s.partition,
+	}
+
+}
+
+// Client returns a client that uses the fake
+func (s *Server) Client(opts ...buildingx.Option) (*buildingx.Client, error) {
+	return buildingx.NewClient(s.Config(), opts...)
+}
+
+// SetPageSize changes the page size of collections. Zero returns every item in a single page.
+func (s *Server) SetPageSize(size int) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pageSize = size
+
+}
+
+// SetLatency changes the delay applied to every response
+func (s *Server) SetLatency(latency time.Duration) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+
+}
+
+// InjectFault adds a fault. When several faults match a request, the first one added applies.
+func (s *Server) InjectFault(fault Fault) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault)
+
+}
+
+// ClearFaults removes every fault
+func (s *Server) ClearFaults() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+
+}
+
+// ExpireTokens invalidates every token issued so far, so the next API call is rejected with a 401
+func (s *Server) ExpireTokens() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]time.Time)
+
+}
+
+// TokenRequests returns the number of tokens issued
+func (s *Server) TokenRequests() int {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenCount
+
+}
+
+// Requests returns every request received, in order
+func (s *Server) Requests() []Request {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+
+}
+
+// Commands returns every point command received, in order
+func (s *Server) Commands() []Command {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Command(nil), s.commands...)
+
+}
+
+// Point returns the current state of a point fixture, including the value set by any command
+func (s *Server) Point(id string) (Point, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, point := range s.fixtures.Points {
+		if point.ID == id {
+			return point, true
+		}
+	}
+	return Point{}, false
+
+}
+
+// SetPointValue changes the value of a point fixture, as if the equipment had changed it. The value is the
+// relinquish default of the point, so it is not the present value while a command is in effect.
+func (s *Server) SetPointValue(id, value string) bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.point(id); !ok {
+		return false
+	}
+	s.relinquish[id] = value
+	s.updatePresentValue(id, time.Now().UTC())
+	return true
+
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	partition := ""
+	if strings.HasPrefix(r.URL.Path, apiPrefix) {
+		rest := strings.TrimPrefix(r.URL.Path, apiPrefix)
+		slash := strings.Index(rest, "/")
+		if slash < 0 {
+			writeError(w, http.StatusNotFound, "no resource found at "+r.URL.Path)
+			return
+		}
+		partition, path = rest[:slash], rest[slash+1:]
+	}
+	relative := path
+	if r.URL.RawQuery != "" {
+		relative += "?" + r.URL.RawQuery
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: relative})
+	latency := s.latency
+	fault := s.matchFault(r.Method, relative)
+	s.mu.Unlock()
+
+	if fault != nil {
+		latency += fault.Latency
+	}
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+
+	if fault != nil && fault.Disconnect {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+	if fault != nil && fault.Status != 0 {
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int((fault.RetryAfter+time.Second-1)/time.Second)))
+		}
+		errs := fault.Errors
+		if errs == nil {
+			errs = []buildingx.SBErrorResponse{{Status: strconv.Itoa(fault.Status), Title: http.StatusText(fault.Status), Detail: "injected fault"}}
+		}
+		writeJSON(w, fault.Status, buildingx.SBResponse{Errors: errs})
+		return
+	}
+
+	if path == TokenPath && partition == "" {
+		s.serveToken(w, r)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, apiPrefix) {
+		writeError(w, http.StatusNotFound, "no resource found at "+r.URL.Path)
+		return
+	}
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "the access token is missing, invalid or expired")
+		return
+	}
+	if partition != s.partition {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("access to partition %s is not allowed", partition))
+		return
+	}
+
+	s.serveAPI(w, r, strings.Split(path, "/"))
+
+}
+
+// matchFault returns the first fault that applies to the request and uses up one of its occurrences
+func (s *Server) matchFault(method, path string) *Fault {
+
+	for i, fault := range s.faults {
+		if fault.Method != "" && !strings.EqualFold(fault.Method, method) {
+			continue
+		}
+		if !strings.HasPrefix(path, fault.Path) {
+			continue
+		}
+		matched := *fault
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return &matched
+	}
+	return nil
+
+}
+
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "the token endpoint only accepts POST")
+		return
+	}
+
+	authRequest := buildingx.AuthRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&authRequest); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+	if authRequest.ClientID != s.clientID || authRequest.ClientSecret != s.clientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "access_denied", "error_description": "Unauthorized"})
+		return
+	}
+
+	s.mu.Lock()
+	s.tokenCount++
+	token := fmt.Sprintf("token-%d", s.tokenCount)
+	s.tokens[token] = time.Now().Add(s.tokenLifetime)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, buildingx.SBToken{
+		AccessToken: token,
+		Expiration:  int(s.tokenLifetime / time.Second),
+		TokenType:   "Bearer",
+	})
+
+}
+
+// authorized reports whether the request carries a token issued by the fake that has not expired
+func (s *Server) authorized(r *http.Request) bool {
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiresAt, ok := s.tokens[token]
+	return ok && time.Now().Before(expiresAt)
+
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request, segments []string) {
+
+	query := r.URL.Query()
+	if r.Method == http.MethodPatch && len(segments) == 2 && segments[0] == "points" {
+		s.commandPoint(w, r, segments[1])
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case len(segments) == 1 && segments[0] == "locations":
+		entries := make([]entry, 0)
+		for _, location := range s.fixtures.Locations {
+			entries = append(entries, locationEntry(location))
+		}
+		s.writePage(w, r, entries)
+
+	case len(segments) == 2 && segments[0] == "locations":
+		for _, location := range s.fixtures.Locations {
+			if location.ID == segments[1] {
+				writeEntry(w, locationEntry(location))
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "location "+segments[1]+" was not found")
+
+	case len(segments) == 1 && segments[0] == "devices":
+		locationID := query.Get("filter[hasLocation.data.id]")
+		entries := make([]entry, 0)
+		for _, device := range s.fixtures.Devices {
+			if locationID == "" || device.LocationID == locationID {
+				entries = append(entries, deviceEntry(device))
+			}
+		}
+		s.writePage(w, r, entries)
+
+	case len(segments) == 2 && segments[0] == "devices":
+		device, ok := s.device(segments[1])
+		if !ok {
+			writeError(w, http.StatusNotFound, "device "+segments[1]+" was not found")
+			return
+		}
+		writeEntry(w, deviceEntry(device))
+
+	case len(segments) == 3 && segments[0] == "devices" && segments[2] == "devices":
+		if _, ok := s.device(segments[1]); !ok {
+			writeError(w, http.StatusNotFound, "device "+segments[1]+" was not found")
+			return
+		}
+		entries := make([]entry, 0)
+		for _, device := range s.fixtures.Devices {
+			if device.GatewayID == segments[1] {
+				entries = append(entries, deviceEntry(device))
+			}
+		}
+		s.writePage(w, r, entries)
+
+	case len(segments) == 3 && segments[0] == "devices" && segments[2] == "points":
+		if _, ok := s.device(segments[1]); !ok {
+			writeError(w, http.StatusNotFound, "device "+segments[1]+" was not found")
+			return
+		}
+		entries := make([]entry, 0)
+		for _, point := range s.fixtures.Points {
+			if point.DeviceID == segments[1] {
+				entries = append(entries, pointEntry(point))
+			}
+		}
+		s.writePage(w, r, entries)
+
+	case len(segments) == 2 && segments[0] == "points":
+		point, ok := s.point(segments[1])
+		if !ok {
+			writeError(w, http.StatusNotFound, "point "+segments[1]+" was not found")
+			return
+		}
+		writeEntry(w, pointEntry(point))
+
+	case len(segments) == 3 && segments[0] == "points" && segments[2] == "values":
+		if _, ok := s.point(segments[1]); !ok {
+			writeError(w, http.StatusNotFound, "point "+segments[1]+" was not found")
+			return
+		}
+		from, fromOK := parseFilterTime(query.Get("filter[timestamp][from]"))
+		to, toOK := parseFilterTime(query.Get("filter[timestamp][to]"))
+		entries := make([]entry, 0)
+		for _, record := range s.fixtures.History[segments[1]] {
+			timestamp, err := time.Parse(time.RFC3339, record.Timestamp)
+			if err == nil && ((fromOK && timestamp.Before(from)) || (toOK && timestamp.After(to))) {
+				continue
+			}
+			entries = append(entries, historyEntry(record))
+		}
+		s.writePage(w, r, entries)
+
+	default:
+		writeError(w, http.StatusNotFound, "no resource found at "+r.URL.Path)
+	}
+
+}
+
+func (s *Server) commandPoint(w http.ResponseWriter, r *http.Request, id string) {
+
+	command := buildingx.SBPointCommand{}
+	if err := json.NewDecoder(r.Body).Decode(&command); err != nil {
+		writeError(w, http.StatusBadRequest, "unable to parse the command: "+err.Error())
+		return
+	}
+	if command.Data.ID != id {
+		writeError(w, http.StatusBadRequest, "the id of the command does not match the point "+id)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	point, ok := s.point(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "point "+id+" was not found")
+		return
+	}
+	if !point.Writable {
+		writeError(w, http.StatusBadRequest, "point "+id+" is not writable")
+		return
+	}
+
+	pointValue := command.Data.Attributes.PointValue
+	priority := pointValue.Priority
+	if priority == 0 {
+		priority = buildingx.PriorityDefault
+	}
+	if priority < 1 || priority > 16 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("priority %d is not between 1 and 16", priority))
+		return
+	}
+
+	now := time.Now().UTC()
+	s.commands = append(s.commands, Command{PointID: id, Value: pointValue.Value, Priority: priority, Release: pointValue.Release, Time: now})
+	s.setPriority(id, priority, pointValue.Value, pointValue.Release)
+	s.updatePresentValue(id, now)
+
+	point, _ = s.point(id)
+	writeEntry(w, pointEntry(point))
+
+}
+
+// setPriority sets or releases a slot of the priority array of a point. The caller must hold s.mu.
+func (s *Server) setPriority(id string, priority int, value string, release bool) {
+
+	for i := range s.fixtures.Points {
+		if s.fixtures.Points[i].ID != id {
+			continue
+		}
+		slots := make([]buildingx.PriorityValue, 0)
+		for _, slot := range s.fixtures.Points[i].PriorityArray {
+			if slot.Priority != priority {
+				slots = append(slots, slot)
+			}
+		}
+		if !release {
+			slots = append(slots, buildingx.PriorityValue{Priority: priority, Value: value})
+		}
+		sort.Slice(slots, func(a, b int) bool { return slots[a].Priority < slots[b].Priority })
+		if len(slots) == 0 {
+			slots = nil
+		}
+		s.fixtures.Points[i].PriorityArray = slots
+	}
+
+}
+
+// updatePresentValue sets the present value of a point from the highest occupied slot of its priority array,
+// or from its relinquish default, and records a change in the point history. The caller must hold s.mu.
+func (s *Server) updatePresentValue(id string, at time.Time) {
+
+	for i := range s.fixtures.Points {
+		point := &s.fixtures.Points[i]
+		if point.ID != id {
+			continue
+		}
+		value, priority := s.relinquish[id], 0
+		if len(point.PriorityArray) > 0 {
+			value, priority = point.PriorityArray[0].Value, point.PriorityArray[0].Priority
+		}
+		point.StringValue = value
+		point.Priority = priority
+		point.Timestamp = at
+		s.fixtures.History[id] = append(s.fixtures.History[id], buildingx.PointHistory{Value: value, Timestamp: at.Format(time.RFC3339)})
+	}
+
+}
+
+func (s *Server) device(id string) (Device, bool) {
+
+	for _, device := range s.fixtures.Devices {
+		if device.ID == id {
+			return device, true
+		}
+	}
+	return Device{}, false
+
+}
+
+func (s *Server) point(id string) (Point, bool) {
+
+	for _, point := range s.fixtures.Points {
+		if point.ID == id {
+			return point, true
+		}
+	}
+	return Point{}, false
+
+}
+
+// writePage writes the page of entries selected by the page[number] and page[size] query parameters, along
+// with the JSON:API links to the next page. The caller must hold s.mu.
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, entries []entry) {
+
+	size := s.pageSize
+	if requested, err := strconv.Atoi(r.URL.Query().Get("page[size]")); err == nil && requested > 0 {
+		size = requested
+	}
+	if size <= 0 || size > len(entries) {
+		size = len(entries)
+	}
+	number, err := strconv.Atoi(r.URL.Query().Get("page[number]"))
+	if err != nil || number < 1 {
+		number = 1
+	}
+
+	start, end := len(entries), len(entries)
+	if size > 0 {
+		start = minInt((number-1)*size, len(entries))
+		end = minInt(start+size, len(entries))
+	}
+
+	links := buildingx.SBPageLinks{Self: s.URL + r.URL.RequestURI()}
+	if end < len(entries) {
+		query := r.URL.Query()
+		query.Set("page[number]", strconv.Itoa(number+1))
+		query.Set("page[size]", strconv.Itoa(size))
+		links.Next = s.URL + r.URL.Path + "?" + strings.NewReplacer("%5B", "[", "%5D", "]").Replace(query.Encode())
+	}
+
+	data := make([]interface{}, 0)
+	included := make([]interface{}, 0)
+	for _, e := range entries[start:end] {
+		data = append(data, e.data)
+		included = append(included, e.included...)
+	}
+
+	writeJSON(w, http.StatusOK, collectionDocument{
+		Data:     data,
+		Included: included,
+		Links:    links,
+		Meta:     buildingx.SBPageMeta{TotalCount: len(entries)},
+	})
+
+}
+
+// entry is a resource with the resources included along with it
+type entry struct {
+	data     interface{}
+	included []interface{}
+}
+
+type collectionDocument struct {
+	Data     []interface{}         `json:"data"`
+	Included []interface{}         `json:"included,omitempty"`
+	Links    buildingx.SBPageLinks `json:"links"`
+	Meta     buildingx.SBPageMeta  `json:"meta"`
+}
+
+type resourceDocument struct {
+	Data     interface{}   `json:"data"`
+	Included []interface{} `json:"included,omitempty"`
+}
+
+func locationEntry(location buildingx.Location) entry {
+
+	addressID := "address-" + location.ID
+	sbLocation := buildingx.SBLocation{
+		ID: location.ID,
+		Attributes: buildingx.SBLocationAttributes{
+			TimeZone:    location.TimeZone,
+			Label:       location.Name,
+			Description: location.Description,
+		},
+	}
+	sbLocation.Relationships.Features.Data = buildingx.SBLocationPostalAddressData{ID: addressID, Type: "PostalAddress"}
+	address := buildingx.SBLocationIncluded{
+		ID:   addressID,
+		Type: "PostalAddress",
+		Attributes: buildingx.SBLocationIncludedAttributes{
+			Locality:    location.City,
+			CountryCode: location.Country,
+			PostalCode:  location.PostalCode,
+			Street:      location.Street,
+		},
+	}
+	return entry{data: sbLocation, included: []interface{}{address}}
+
+}
+
+func deviceEntry(device Device) entry {
+
+	sbDevice := buildingx.SBDevice{
+		ID: device.ID,
+		Attributes: buildingx.SBDeviceAttributes{
+			ModelName:    device.Model,
+			SerialNumber: device.Serial,
+		},
+	}
+	hasDevice := buildingx.SBDeviceIncludedRelationships{}
+	hasDevice.HasDevice.Data = buildingx.SBDeviceIncludedRelationshipsData{ID: device.ID, Type: "Device"}
+	info := buildingx.SBDeviceIncluded{
+		ID:            "info-" + device.ID,
+		Type:          "DeviceInfo",
+		Attributes:    buildingx.SBDeviceIncludedAttributes{Name: device.Name, Description: device.Description},
+		RelationShips: hasDevice,
+	}
+	connectivity := buildingx.SBDeviceIncluded{
+		ID:            "connectivity-" + device.ID,
+		Type:          "Connectivity",
+		Attributes:    buildingx.SBDeviceIncludedAttributes{Status: device.OnlineStatus},
+		RelationShips: hasDevice,
+	}
+	sbDevice.RelationShips.Features.Data = []buildingx.SBDeviceFeaturesData{
+		{ID: info.ID, Type: info.Type},
+		{ID: connectivity.ID, Type: connectivity.Type},
+	}
+	return entry{data: sbDevice, included: []interface{}{info, connectivity}}
+
+}
+
+func pointEntry(point Point) entry {
+
+	writable := ""
+	if point.Writable {
+		writable = "m:"
+	}
+	timestamp := ""
+	if !point.Timestamp.IsZero() {
+		timestamp = point.Timestamp.Format(time.RFC3339Nano)
+	}
+	system := buildingx.SBPointSystemAttributes{
+		CurStatus:       point.Status,
+		Description:     point.Description,
+		Writable:        writable,
+		Units:           point.Units,
+		MinPresValue:    buildingx.NewSBNumber(point.Min),
+		MaxPresValue:    buildingx.NewSBNumber(point.Max),
+		Resolution:      buildingx.NewSBNumber(point.Resolution),
+		ObjectReference: point.BACnetReference,
+	}
+	if strings.EqualFold(point.DataType, buildingx.DataTypeBoolean) && len(point.StateTexts) == 2 {
+		system.InactiveText, system.ActiveText = point.StateTexts[0], point.StateTexts[1]
+	} else {
+		system.StateText = point.StateTexts
+	}
+	sbPoint := buildingx.SBPoint{
+		ID: point.ID,
+		Attributes: buildingx.SBPointAttributes{
+			Name:             point.Name,
+			DataType:         point.DataType,
+			SystemAttributes: system,
+			PointValue:       buildingx.SBPointValue{Value: point.StringValue, Timestamp: timestamp},
+		},
+	}
+	if len(point.PriorityArray) > 0 {
+		slots := make([]interface{}, 16)
+		for _, slot := range point.PriorityArray {
+			if slot.Priority >= 1 && slot.Priority <= 16 {
+				slots[slot.Priority-1] = slot.Value
+			}
+		}
+		sbPoint.Attributes.PointValue.PriorityArray = slots
+		sbPoint.Attributes.PointValue.Priority = buildingx.SBNumber{Value: float64(point.PriorityArray[0].Priority), Valid: true}
+	}
+	if len(point.Attributes) == 0 {
+		return entry{data: sbPoint}
+	}
+
+	// the fixture attributes that are not modeled are served along with the modeled ones
+	attributes := make(map[string]interface{})
+	modeled, _ := json.Marshal(sbPoint.Attributes)
+	json.Unmarshal(modeled, &attributes)
+	for key, value := range point.Attributes {
+		if _, ok := attributes[key]; !ok {
+			attributes[key] = value
+		}
+	}
+	return entry{data: map[string]interface{}{"id": point.ID, "attributes": attributes}}
+
+}
+
+func historyEntry(record buildingx.PointHistory) entry {
+
+	return entry{data: buildingx.SBPointHistory{
+		Attributes: buildingx.SBPointHistoryAttributes{Value: record.Value, Timestamp: record.Timestamp},
+	}}
+
+}
+
+func writeEntry(w http.ResponseWriter, e entry) {
+	writeJSON(w, http.StatusOK, resourceDocument{Data: e.data, Included: e.included})
+}
+
+func writeError(w http.ResponseWriter, status int, detail string) {
+
+	writeJSON(w, status, buildingx.SBResponse{Errors: []buildingx.SBErrorResponse{
+		{Status: strconv.Itoa(status), Title: http.StatusText(status), Detail: detail},
+	}})
+
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+
+	w.Header().Set("content-type", "application/vnd.api+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+
+}
+
+// parseFilterTime parses a timestamp filter. A + in an unescaped time zone offset arrives as a space.
+func parseFilterTime(value string) (time.Time, bool) {
+
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, strings.ReplaceAll(value, " ", "+"))
+	return t, err == nil
+
+}
+
+func copyFixtures(fixtures Fixtures) Fixtures {
+
+	copied := Fixtures{
+		Locations: append([]buildingx.Location(nil), fixtures.Locations...),
+		Devices:   append([]Device(nil), fixtures.Devices...),
+		Points:    append([]Point(nil), fixtures.Points...),
+		History:   make(map[string][]buildingx.PointHistory, len(fixtures.History)),
+	}
+	for id, history := range fixtures.History {
+		copied.History[id] = append([]buildingx.PointHistory(nil), history...)
+	}
+	return copied
+
+}
+
+func minInt(a, b int) int {
+
+	if a < b {
+		return a
+	}
+	return b
+
+}
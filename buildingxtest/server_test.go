@@ -0,0 +1,269 @@
+package buildingxtest
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+
+	t.Run("locations-devices-and-points", func(t *testing.T) {
+		server := NewServer(DefaultFixtures())
+		defer server.Close()
+		client, err := server.Client()
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		locations, err := client.GetLocations()
+		if err != nil {
+			t.Fatal("error getting locations: ", err.Error())
+		}
+		assert.Equal(t, 1, len(locations))
+		assert.Equal(t, DefaultFixtures().Locations[0], locations[0])
+
+		devices, err := client.GetDevicesByLocation(&locations[0])
+		if err != nil {
+			t.Fatal("error getting devices: ", err.Error())
+		}
+		assert.Equal(t, 2, len(devices))
+
+		gatewayDevices, err := client.GetDevicesByGateway("gateway-1")
+		if err != nil {
+			t.Fatal("error getting gateway devices: ", err.Error())
+		}
+		assert.Equal(t, 1, len(gatewayDevices))
+		assert.Equal(t, DefaultFixtures().Devices[1].Device, gatewayDevices[0])
+
+		points, err := client.GetPointsByDevice(&gatewayDevices[0])
+		if err != nil {
+			t.Fatal("error getting points: ", err.Error())
+		}
+		assert.Equal(t, 4, len(points))
+		assert.NotNil(t, points[0].Attributes["systemAttributes"])
+		points[0].Attributes = nil
+		assert.Equal(t, DefaultFixtures().Points[0].Point, points[0])
+
+		_, err = client.GetSingleDevice("missing")
+		assert.True(t, buildingx.IsNotFound(err))
+	})
+	t.Run("command-updates-point", func(t *testing.T) {
+		server := NewServer(DefaultFixtures())
+		defer server.Close()
+		client, err := server.Client()
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		point, err := client.GetSinglePoint("point-1")
+		if err != nil {
+			t.Fatal("error getting point: ", err.Error())
+		}
+		if err := client.CommandPointValue(&point, "true"); err != nil {
+			t.Fatal("error commanding point: ", err.Error())
+		}
+
+		point, err = client.GetSinglePoint("point-1")
+		if err != nil {
+			t.Fatal("error getting point: ", err.Error())
+		}
+		assert.Equal(t, "true", point.StringValue)
+		assert.Equal(t, 1, len(server.Commands()))
+		assert.Equal(t, "true", server.Commands()[0].Value)
+	})
+	t.Run("history-is-filtered", func(t *testing.T) {
+		server := NewServer(DefaultFixtures())
+		defer server.Close()
+		client, err := server.Client()
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		point := buildingx.Point{ID: "point-2"}
+		start := time.Date(2022, 5, 1, 10, 30, 0, 0, time.UTC)
+		history, err := client.GetPointHistory(&point, start, start.Add(24*time.Hour))
+		if err != nil {
+			t.Fatal("error getting history: ", err.Error())
+		}
+		assert.Equal(t, []string{"55.1", "55.2"}, []string{history[0].Value, history[1].Value})
+
+		records, err := client.GetPointHistoryRecords(&point, start, start.Add(time.Hour), buildingx.HistoryPageSize(1))
+		if err != nil {
+			t.Fatal("error getting history records: ", err.Error())
+		}
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, "55.1", records[0].Raw)
+		assert.True(t, records[0].Time.After(start))
+	})
+	t.Run("pagination", func(t *testing.T) {
+		server := NewServer(DefaultFixtures(), WithPageSize(1))
+		defer server.Close()
+		client, err := server.Client()
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		pages := 0
+		err = client.GetPointsByDevicePages(&buildingx.Device{ID: "device-1"}, func(points []buildingx.Point, lastPage bool) bool {
+			pages++
+			assert.Equal(t, 1, len(points))
+			assert.Equal(t, pages == 4, lastPage)
+			return true
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 4, pages)
+	})
+	t.Run("injected-faults", func(t *testing.T) {
+		server := NewServer(DefaultFixtures())
+		defer server.Close()
+		client, err := server.Client(buildingx.WithRetryPolicy(buildingx.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		server.InjectFault(Fault{Path: "locations", Status: 503, Times: 2})
+		_, err = client.GetLocations()
+		assert.Nil(t, err)
+
+		server.InjectFault(Fault{Method: "GET", Path: "points/point-1", Status: 403})
+		_, err = client.GetSinglePoint("point-1")
+		assert.True(t, buildingx.IsForbidden(err))
+		server.ClearFaults()
+		_, err = client.GetSinglePoint("point-1")
+		assert.Nil(t, err)
+
+		server.InjectFault(Fault{Path: "devices", Disconnect: true, Times: 3})
+		_, err = client.GetAllDevices()
+		var urlErr *url.Error
+		assert.True(t, errors.As(err, &urlErr))
+	})
+	t.Run("latency", func(t *testing.T) {
+		server := NewServer(DefaultFixtures(), WithLatency(time.Second))
+		defer server.Close()
+		client, err := server.Client()
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = client.GetLocationsWithContext(ctx)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+	t.Run("expired-token-is-refreshed", func(t *testing.T) {
+		server := NewServer(DefaultFixtures())
+		defer server.Close()
+		client, err := server.Client()
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		_, err = client.GetLocations()
+		assert.Nil(t, err)
+		server.ExpireTokens()
+		_, err = client.GetLocations()
+		assert.Nil(t, err)
+		assert.Equal(t, 2, server.TokenRequests())
+	})
+	t.Run("bad-credentials", func(t *testing.T) {
+		server := NewServer(DefaultFixtures(), WithCredentials("id", "secret"))
+		defer server.Close()
+		client, err := buildingx.NewClient(server.Config(), buildingx.WithCredentials("id", "wrong"))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		_, err = client.GetLocations()
+		assert.True(t, buildingx.IsUnauthorized(err))
+	})
+	t.Run("other-partition-is-forbidden", func(t *testing.T) {
+		server := NewServer(DefaultFixtures())
+		defer server.Close()
+		client, err := server.Client()
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		session, err := client.NewSession("other")
+		if err != nil {
+			t.Fatal("error creating session: ", err.Error())
+		}
+		_, err = buildingx.GetLocations(session)
+		assert.True(t, buildingx.IsForbidden(err))
+	})
+
+}
+
+func TestLoadFixtures(t *testing.T) {
+
+	fixtures, err := LoadFixtures("testdata/site.json")
+	if err != nil {
+		t.Fatal("error loading fixtures: ", err.Error())
+	}
+	server := NewServer(fixtures)
+	defer server.Close()
+	client, err := server.Client()
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+
+	devices, err := client.GetAllDevices()
+	if err != nil {
+		t.Fatal("error getting devices: ", err.Error())
+	}
+	assert.Equal(t, "X200", devices[0].Model)
+	assert.Equal(t, "offline", devices[1].OnlineStatus)
+	history, err := client.GetPointHistory(&buildingx.Point{ID: "point-1"}, time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal("error getting history: ", err.Error())
+	}
+	assert.Equal(t, 2, len(history))
+
+}
+
+func TestServerPriorities(t *testing.T) {
+
+	server := NewServer(DefaultFixtures())
+	defer server.Close()
+	client, err := server.Client()
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+	setpoint, err := client.GetSinglePoint("point-3")
+	if err != nil {
+		t.Fatal("error getting point: ", err.Error())
+	}
+
+	t.Run("highest-priority-wins", func(t *testing.T) {
+		assert.Nil(t, client.CommandNumber(&setpoint, 70))
+		assert.Nil(t, client.CommandNumber(&setpoint, 68, buildingx.Priority(buildingx.PriorityManualOperator)))
+		point, _ := client.GetSinglePoint("point-3")
+		assert.Equal(t, "68", point.StringValue)
+		assert.Equal(t, 8, point.Priority)
+		assert.Equal(t, 2, len(point.PriorityArray))
+	})
+	t.Run("release-falls-back", func(t *testing.T) {
+		assert.Nil(t, client.ReleasePointCommand(&setpoint, buildingx.Priority(8)))
+		point, _ := client.GetSinglePoint("point-3")
+		assert.Equal(t, "70", point.StringValue)
+		assert.Equal(t, 16, point.Priority)
+
+		assert.Nil(t, client.ReleasePointCommand(&setpoint))
+		point, _ = client.GetSinglePoint("point-3")
+		assert.Equal(t, "72", point.StringValue)
+		assert.Equal(t, 0, point.Priority)
+		assert.Equal(t, 0, len(point.PriorityArray))
+	})
+	t.Run("commands-are-recorded", func(t *testing.T) {
+		commands := server.Commands()
+		assert.Equal(t, 4, len(commands))
+		assert.Equal(t, Command{PointID: "point-3", Priority: 8, Release: true, Time: commands[2].Time}, commands[2])
+	})
+
+}
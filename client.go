@@ -0,0 +1,256 @@
+package buildingx
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// defaultTimeout is the HTTP timeout used when the configuration does not provide one
+const defaultTimeout = 20 * time.Second
+
+// Config holds everything a Client needs to talk to a Building X tenant
+type Config struct {
+	Endpoint        string
+	AuthURL         string
+	Audience        string
+	ClientID        string
+	ClientSecret    string
+	Partition       string
+	HTTPClient      *http.Client
+	Transport       http.RoundTripper
+	Middleware      []Middleware
+	Timeout         time.Duration
+	RetryPolicy     RetryPolicy
+	RateLimit       RateLimit
+	CallObserver    func(CallInfo)
+	XRay            bool
+	Instrumentation Instrumentation
+	DryRun          bool
+	DryRunLogger    *log.Logger
+}
+
+// Option modifies a Config while a Client is being created
+type Option func(*Config)
+
+// Client talks to a single Building X tenant using an explicit configuration. Unlike the package-level
+// functions, a Client never reads the process environment, so several clients can be used side by side.
+type Client struct {
+	config           Config
+	httpClient       *http.Client
+	tracedHTTPClient *http.Client
+	limiter          *limiter
+	session          *Session
+}
+
+// ConfigFromEnv builds a Config from the BUILDINGX_* environment variables
+func ConfigFromEnv() Config {
+
+	return Config{
+		Endpoint:     os.Getenv("BUILDINGX_ENDPOINT"),
+		AuthURL:      os.Getenv("BUILDINGX_AUTH_URL"),
+		Audience:     os.Getenv("BUILDINGX_AUDIENCE"),
+		ClientID:     os.Getenv("BUILDINGX_CLIENT_ID"),
+		ClientSecret: os.Getenv("BUILDINGX_CLIENT_SECRET"),
+	}
+
+}
+
+// WithEndpoint sets the Operations API endpoint (ex: https://api.bpcloud.siemens.com)
+func WithEndpoint(endpoint string) Option {
+	return func(c *Config) { c.Endpoint = endpoint }
+}
+
+// WithAuthURL sets the URL of the OAuth token endpoint
+func WithAuthURL(authURL string) Option {
+	return func(c *Config) { c.AuthURL = authURL }
+}
+
+// WithAudience sets the audience requested from the OAuth provider
+func WithAudience(audience string) Option {
+	return func(c *Config) { c.Audience = audience }
+}
+
+// WithCredentials sets the client ID and client secret used to obtain tokens
+func WithCredentials(clientID, clientSecret string) Option {
+	return func(c *Config) {
+		c.ClientID = clientID
+		c.ClientSecret = clientSecret
+	}
+}
+
+// WithPartition sets the partition the client operates on
+func WithPartition(partition string) Option {
+	return func(c *Config) { c.Partition = partition }
+}
+
+// WithHTTPClient sets the HTTP client used for every call. When set, Timeout is ignored. If a transport or
+// middleware is also configured, the client is copied rather than modified.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Config) { c.HTTPClient = httpClient }
+}
+
+// WithTimeout sets the timeout of the HTTP client created by the library
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Config) { c.Timeout = timeout }
+}
+
+// NewClient validates the configuration, after applying any options, and returns a new Client. No call
+// is made to Building X until the client is first used.
+func NewClient(config Config, opts ...Option) (*Client, error) {
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if config.Endpoint == "" {
+		return nil, &ConfigError{Setting: "buildingx api endpoint"}
+	}
+	if config.AuthURL == "" {
+		return nil, &ConfigError{Setting: "authorization URL"}
+	}
+	if config.Audience == "" {
+		return nil, &ConfigError{Setting: "audience"}
+	}
+	if config.ClientID == "" {
+		return nil, &ConfigError{Setting: "client id"}
+	}
+	if config.ClientSecret == "" {
+		return nil, &ConfigError{Setting: "client secret"}
+	}
+	if config.Partition == "" {
+		return nil, errors.New("partition cannot be empty")
+	}
+
+	c := newClient(config)
+	c.session = &Session{
+		Partition:   config.Partition,
+		client:      c,
+		tokenSource: c.requestToken,
+	}
+
+	return c, nil
+
+}
+
+// newClient creates a client without validating the configuration
+func newClient(config Config) *Client {
+
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	c := &Client{
+		config:     config,
+		httpClient: buildHTTPClient(config),
+		limiter:    newLimiter(config.RateLimit),
+	}
+	if config.XRay {
+		c.tracedHTTPClient = xray.Client(c.httpClient)
+	}
+
+	return c
+
+}
+
+// envClient returns a client configured from the environment. It backs the package-level functions,
+// which have always read the environment on every call.
+func envClient() *Client {
+	return newClient(ConfigFromEnv())
+}
+
+// Config returns the configuration of the client
+func (c *Client) Config() Config {
+	return c.config
+}
+
+// Session returns the session used by the client methods. Its token is obtained on first use.
+func (c *Client) Session() *Session {
+	return c.session
+}
+
+// NewSession authenticates and returns a session for another partition that shares the client configuration
+func (c *Client) NewSession(partition string) (*Session, error) {
+	return c.NewSessionWithContext(context.Background(), partition)
+}
+
+// NewSessionWithContext is like NewSession but uses the given context for the credential exchange
+func (c *Client) NewSessionWithContext(ctx context.Context, partition string) (*Session, error) {
+
+	if partition == "" {
+		return nil, errors.New("partition cannot be empty")
+	}
+
+	session := &Session{
+		Partition:   partition,
+		client:      c,
+		tokenSource: c.requestToken,
+	}
+	if err := session.RefreshWithContext(ctx); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+
+}
+
+// call makes the API request on behalf of the session. The session partition and a fresh token are
+// applied to the request, and a request rejected with a 401 is retried once with a new token.
+func (c *Client) call(ctx context.Context, session *Session, apiReq APIRequest) ([]byte, error) {
+
+	if apiReq.Operation != GET {
+		if err := c.dryRunWrite(session, apiReq); err != nil {
+			return make([]byte, 0), err
+		}
+	}
+
+	if inOperation(ctx) {
+		return c.callWithToken(ctx, session, apiReq)
+	}
+
+	var resp []byte
+	err := c.operation(ctx, apiReq.Name, session, func(ctx context.Context) error {
+		var err error
+		resp, err = c.callWithToken(ctx, session, apiReq)
+		return err
+	})
+	return resp, err
+
+}
+
+func (c *Client) callWithToken(ctx context.Context, session *Session, apiReq APIRequest) ([]byte, error) {
+
+	token, err := session.TokenWithContext(ctx)
+	if err != nil {
+		return make([]byte, 0), err
+	}
+
+	apiReq.Partition = session.PartitionID()
+	session.mu.RLock()
+	canRefresh := session.tokenSource != nil
+	session.mu.RUnlock()
+
+	apiReq.JWT = token
+	resp, status, err := c.makeRESTCallWithRetry(ctx, apiReq)
+	if status != 401 || !canRefresh {
+		return resp, err
+	}
+
+	// the token was rejected, so get a new one and try one more time
+	if err := session.refresh(ctx, token); err != nil {
+		return resp, err
+	}
+	token, err = session.TokenWithContext(ctx)
+	if err != nil {
+		return make([]byte, 0), err
+	}
+	apiReq.JWT = token
+	resp, _, err = c.makeRESTCallWithRetry(ctx, apiReq)
+	return resp, err
+
+}
@@ -0,0 +1,119 @@
+package buildingx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestConfig starts an auth server and an API server backed by the given handler and returns a configuration that uses them
+func newTestConfig(t *testing.T, api http.Handler) Config {
+
+	authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"access_token":"token","expires_in":3600,"token_type":"Bearer"}`)
+	}))
+	t.Cleanup(authServer.Close)
+
+	apiServer := httptest.NewServer(api)
+	t.Cleanup(apiServer.Close)
+
+	return Config{
+		Endpoint:     apiServer.URL,
+		AuthURL:      authServer.URL,
+		Audience:     "audience",
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Partition:    "partition",
+	}
+
+}
+
+// integrationPartition returns the partition the integration tests run against. Those tests need a Building X
+// account, so they are skipped unless BUILDINGX_PARTITION_ID and the API credentials are set.
+func integrationPartition(t *testing.T) string {
+
+	for _, name := range []string{"BUILDINGX_PARTITION_ID", "BUILDINGX_CLIENT_ID", "BUILDINGX_CLIENT_SECRET", "BUILDINGX_AUDIENCE", "BUILDINGX_AUTH_URL", "BUILDINGX_ENDPOINT"} {
+		if os.Getenv(name) == "" {
+			t.Skip("skipping integration test because " + name + " is not set")
+		}
+	}
+	return os.Getenv("BUILDINGX_PARTITION_ID")
+
+}
+
+// newTestTenant returns a configuration for an API that returns a single location with the given name
+func newTestTenant(t *testing.T, locationName string) Config {
+
+	return newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":[{"id":"loc-1","attributes":{"label":"%s"}}]}`, locationName)
+	}))
+
+}
+
+func TestNewClient(t *testing.T) {
+
+	t.Run("two-clients-side-by-side", func(t *testing.T) {
+		// make sure nothing is read from the environment
+		t.Setenv("BUILDINGX_ENDPOINT", "")
+
+		staging, err := NewClient(newTestTenant(t, "staging"))
+		if err != nil {
+			t.Fatal("error creating staging client: ", err.Error())
+		}
+		production, err := NewClient(newTestTenant(t, "production"))
+		if err != nil {
+			t.Fatal("error creating production client: ", err.Error())
+		}
+
+		stagingLocations, err := staging.GetLocations()
+		if err != nil {
+			t.Fatal("error getting staging locations: ", err.Error())
+		}
+		productionLocations, err := production.GetLocations()
+		if err != nil {
+			t.Fatal("error getting production locations: ", err.Error())
+		}
+		assert.Equal(t, "staging", stagingLocations[0].Name)
+		assert.Equal(t, "production", productionLocations[0].Name)
+	})
+	t.Run("options-override-config", func(t *testing.T) {
+		client, err := NewClient(newTestTenant(t, "name"), WithPartition("other-partition"))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+		assert.Equal(t, "other-partition", client.Session().Partition)
+	})
+	t.Run("context-deadline-is-honored", func(t *testing.T) {
+		config := newTestTenant(t, "name")
+		slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		defer slowServer.Close()
+
+		client, err := NewClient(config, WithEndpoint(slowServer.URL))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err = client.GetLocationsWithContext(ctx)
+		assert.NotNil(t, err)
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	})
+	t.Run("missing-configuration", func(t *testing.T) {
+		_, err := NewClient(Config{})
+		assert.NotNil(t, err)
+	})
+
+}
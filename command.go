@@ -0,0 +1,156 @@
+package buildingx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrPointNotWritable is returned when a command is made on a point that is not writable
+	ErrPointNotWritable = errors.New("point is not writable")
+	// ErrInvalidPriority is returned when a command priority is not between 1 and 16
+	ErrInvalidPriority = errors.New("command priority must be between 1 and 16")
+)
+
+// Common BACnet command priorities
+const (
+	PriorityManualLifeSafety  = 1
+	PriorityAutoLifeSafety    = 2
+	PriorityCriticalEquipment = 5
+	PriorityMinimumOnOff      = 6
+	PriorityManualOperator    = 8
+	PriorityDefault           = 16
+)
+
+// CommandOption changes how a point command is made
+type CommandOption func(*commandOptions)
+
+type commandOptions struct {
+	force         bool
+	priority      int
+	verifyTimeout time.Duration
+	verifyPolling RetryPolicy
+}
+
+func newCommandOptions(opts []CommandOption) commandOptions {
+
+	options := commandOptions{
+		verifyTimeout: defaultVerifyTimeout,
+		verifyPolling: RetryPolicy{BaseDelay: defaultVerifyFirst, MaxDelay: defaultVerifyMaxDelay},
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+
+}
+
+// Force sends a command without validating the value against the data type, limits and states of the point.
+// The point must still be writable.
+func Force() CommandOption {
+	return func(o *commandOptions) { o.force = true }
+}
+
+// Priority commands (or releases) the point at a BACnet priority from 1 (highest) to 16 (lowest). Without
+// it the API chooses the priority.
+func Priority(level int) CommandOption {
+	return func(o *commandOptions) { o.priority = level }
+}
+
+// validPriority checks the priority option, which is zero when it was not given
+func (o commandOptions) validPriority() error {
+
+	if o.priority < 0 || o.priority > 16 {
+		return fmt.Errorf("%w: %d", ErrInvalidPriority, o.priority)
+	}
+	return nil
+
+}
+
+// check makes the checks done before a point is commanded with value
+func (o commandOptions) check(point *Point, value string) error {
+
+	if !point.Writable {
+		return ErrPointNotWritable
+	}
+	if err := o.validPriority(); err != nil {
+		return err
+	}
+	if !o.force {
+		return ValidateCommand(point, value)
+	}
+	return nil
+
+}
+
+// ValidateCommand checks a value before it is commanded to a point. The Building X API accepts invalid values
+// silently, so the value is checked against the data type of the point, its present value limits and its
+// states (for enumerated points and numeric points with state texts). The checks that need metadata the
+// point does not have are skipped. The error is a ValueError, which matches ErrInvalidValue.
+func ValidateCommand(point *Point, value string) error {
+
+	v, err := ParseValue(point.DataType, value)
+	if err != nil {
+		var valueErr *ValueError
+		if errors.As(err, &valueErr) {
+			valueErr.PointID = point.ID
+		}
+		return err
+	}
+
+	invalid := func(reason string) error {
+		return &ValueError{PointID: point.ID, DataType: point.DataType, Value: value, Reason: reason}
+	}
+
+	notState := func() error {
+		states := make([]string, len(point.StateTexts))
+		for i, text := range point.StateTexts {
+			states[i] = fmt.Sprintf("%d (%s)", i+1, text)
+		}
+		return invalid("not one of the states " + strings.Join(states, ", "))
+	}
+
+	switch v.Kind() {
+	case KindNumber:
+		if point.Min != nil && v.f < *point.Min {
+			return invalid("below the minimum of " + formatFloat(*point.Min))
+		}
+		if point.Max != nil && v.f > *point.Max {
+			return invalid("above the maximum of " + formatFloat(*point.Max))
+		}
+		// a numeric point with state texts is a multistate point reported as a number
+		if len(point.StateTexts) > 0 && (v.f != math.Trunc(v.f) || v.f < 1 || v.f > float64(len(point.StateTexts))) {
+			return notState()
+		}
+	case KindEnum:
+		if len(point.StateTexts) > 0 && (v.n < 1 || v.n > len(point.StateTexts)) {
+			return notState()
+		}
+	}
+
+	return nil
+
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatRaw formats a value decoded from JSON as the API would send it in a string
+func formatRaw(value interface{}) string {
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return formatFloat(v)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+
+}
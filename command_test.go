@@ -0,0 +1,126 @@
+package buildingx
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCommand(t *testing.T) {
+
+	lower, upper := 55.0, 85.0
+	setpoint := &Point{ID: "setpoint", DataType: "number", Writable: true, Min: &lower, Max: &upper}
+	mode := &Point{ID: "mode", DataType: "enum", Writable: true, StateTexts: []string{"Occupied", "Unoccupied"}}
+	fan := &Point{ID: "fan", DataType: "boolean", Writable: true}
+	stages := &Point{ID: "stages", DataType: "number", Writable: true, StateTexts: []string{"Off", "Low", "High"}}
+
+	t.Run("valid-values", func(t *testing.T) {
+		assert.Nil(t, ValidateCommand(setpoint, "72.5"))
+		assert.Nil(t, ValidateCommand(setpoint, "55"))
+		assert.Nil(t, ValidateCommand(mode, "2"))
+		assert.Nil(t, ValidateCommand(stages, "3"))
+		assert.Nil(t, ValidateCommand(fan, "false"))
+		assert.Nil(t, ValidateCommand(&Point{ID: "unknown"}, "anything"))
+	})
+	t.Run("invalid-values", func(t *testing.T) {
+		for point, value := range map[*Point]string{setpoint: "90", fan: "72", mode: "3"} {
+			err := ValidateCommand(point, value)
+			var valueErr *ValueError
+			if !errors.As(err, &valueErr) {
+				t.Fatal("expected a ValueError but got: ", err)
+			}
+			assert.Equal(t, point.ID, valueErr.PointID)
+			assert.True(t, errors.Is(err, ErrInvalidValue))
+		}
+		assert.Equal(t, `invalid number value "40" for point setpoint: below the minimum of 55`, ValidateCommand(setpoint, "40").Error())
+		assert.Equal(t, `invalid enum value "0" for point mode: not one of the states 1 (Occupied), 2 (Unoccupied)`, ValidateCommand(mode, "0").Error())
+		for _, value := range []string{"0", "4", "1.5"} {
+			assert.Equal(t, `invalid number value "`+value+`" for point stages: not one of the states 1 (Off), 2 (Low), 3 (High)`, ValidateCommand(stages, value).Error())
+		}
+	})
+
+}
+
+func TestCommandValidation(t *testing.T) {
+
+	commands := 0
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		commands++
+	}))
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+	upper := 85.0
+	setpoint := &Point{ID: "setpoint", DataType: "number", Writable: true, Max: &upper}
+
+	t.Run("invalid-value-is-not-sent", func(t *testing.T) {
+		err := client.CommandPointValue(setpoint, "900")
+		assert.True(t, errors.Is(err, ErrInvalidValue))
+		err = client.CommandNumber(setpoint, 900)
+		assert.True(t, errors.Is(err, ErrInvalidValue))
+		assert.Equal(t, 0, commands)
+	})
+	t.Run("force-skips-validation", func(t *testing.T) {
+		assert.Nil(t, client.CommandPointValue(setpoint, "900", Force()))
+		assert.Nil(t, client.CommandBool(setpoint, true, Force()))
+		assert.Equal(t, 2, commands)
+	})
+	t.Run("force-still-requires-writable", func(t *testing.T) {
+		err := client.CommandPointValue(&Point{ID: "sensor"}, "1", Force())
+		assert.True(t, errors.Is(err, ErrPointNotWritable))
+	})
+
+}
+
+func TestCommandPriority(t *testing.T) {
+
+	bodies := make([]string, 0)
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			body, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			return
+		}
+		fmt.Fprint(w, `{"data":{"id":"fan","attributes":{"dataType":"boolean","pointValue":{"value":"true",
+			"priorityArray":[null,null,null,null,null,null,null,"true",null,null,null,null,null,null,null,false]}}}}`)
+	}))
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+	fan := &Point{ID: "fan", DataType: "boolean", Writable: true}
+
+	t.Run("command-at-priority", func(t *testing.T) {
+		bodies = bodies[:0]
+		assert.Nil(t, client.CommandBool(fan, true, Priority(PriorityManualOperator)))
+		assert.Nil(t, client.CommandPointValue(fan, "false"))
+		assert.Equal(t, `{"data":{"id":"fan","attributes":{"pointValue":{"value":"true","priority":8}}}}`, bodies[0])
+		assert.Equal(t, `{"data":{"id":"fan","attributes":{"pointValue":{"value":"false"}}}}`, bodies[1])
+	})
+	t.Run("release", func(t *testing.T) {
+		bodies = bodies[:0]
+		assert.Nil(t, client.ReleasePointCommand(fan, Priority(8)))
+		assert.Equal(t, `{"data":{"id":"fan","attributes":{"pointValue":{"value":null,"priority":8}}}}`, bodies[0])
+		assert.True(t, errors.Is(client.ReleasePointCommand(&Point{ID: "sensor"}), ErrPointNotWritable))
+	})
+	t.Run("invalid-priority", func(t *testing.T) {
+		bodies = bodies[:0]
+		assert.True(t, errors.Is(client.CommandPointValue(fan, "true", Priority(17)), ErrInvalidPriority))
+		assert.True(t, errors.Is(client.ReleasePointCommand(fan, Priority(-1)), ErrInvalidPriority))
+		assert.Equal(t, 0, len(bodies))
+	})
+	t.Run("priority-array-is-decoded", func(t *testing.T) {
+		point, err := client.GetSinglePoint("fan")
+		if err != nil {
+			t.Fatal("error getting point: ", err.Error())
+		}
+		assert.Equal(t, 8, point.Priority)
+		assert.Equal(t, []PriorityValue{{Priority: 8, Value: "true"}, {Priority: 16, Value: "false"}}, point.PriorityArray)
+	})
+
+}
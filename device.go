@@ -1,10 +1,10 @@
 package buildingx
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 )
 
@@ -68,106 +68,244 @@ type SBDeviceIncludedRelationshipsData struct {
 
 // returns an array of devices that are associated with a particular location
 func GetDevicesByLocation(session *Session, location *Location) ([]Device, error) {
+	return GetDevicesByLocationWithContext(context.Background(), session, location)
+}
 
-	devices := make([]Device, 0)
+// GetDevicesByLocationWithContext is like GetDevicesByLocation but uses the given context for the API call and any token refresh
+func GetDevicesByLocationWithContext(ctx context.Context, session *Session, location *Location) ([]Device, error) {
+	return session.apiClient().getDevicesByLocation(ctx, session, location)
+}
 
-	// make sure session is initialized
-	if !session.IsInitialized {
-		return devices, errors.New("session is not initialized")
+// GetDevicesByLocation returns an array of devices that are associated with a particular location
+func (c *Client) GetDevicesByLocation(location *Location) ([]Device, error) {
+	return c.GetDevicesByLocationWithContext(context.Background(), location)
+}
+
+// GetDevicesByLocationWithContext is like GetDevicesByLocation but uses the given context for the API call and any token refresh
+func (c *Client) GetDevicesByLocationWithContext(ctx context.Context, location *Location) ([]Device, error) {
+	return c.getDevicesByLocation(ctx, c.session, location)
+}
+
+// GetDevicesByLocationPages calls fn with each page of devices that are associated with a particular location. Iteration stops
+// when fn returns false or the last page has been read.
+func GetDevicesByLocationPages(session *Session, location *Location, fn func(devices []Device, lastPage bool) bool) error {
+	return GetDevicesByLocationPagesWithContext(context.Background(), session, location, fn)
+}
+
+// GetDevicesByLocationPagesWithContext is like GetDevicesByLocationPages but uses the given context for the API calls and any token refresh
+func GetDevicesByLocationPagesWithContext(ctx context.Context, session *Session, location *Location, fn func(devices []Device, lastPage bool) bool) error {
+	return session.apiClient().getDevicesByLocationPages(ctx, session, location, fn)
+}
+
+// GetDevicesByLocationPages calls fn with each page of devices that are associated with a particular location
+func (c *Client) GetDevicesByLocationPages(location *Location, fn func(devices []Device, lastPage bool) bool) error {
+	return c.GetDevicesByLocationPagesWithContext(context.Background(), location, fn)
+}
+
+// GetDevicesByLocationPagesWithContext is like GetDevicesByLocationPages but uses the given context for the API calls and any token refresh
+func (c *Client) GetDevicesByLocationPagesWithContext(ctx context.Context, location *Location, fn func(devices []Device, lastPage bool) bool) error {
+	return c.getDevicesByLocationPages(ctx, c.session, location, fn)
+}
+
+func (c *Client) getDevicesByLocation(ctx context.Context, session *Session, location *Location) ([]Device, error) {
+
+	devices := make([]Device, 0)
+	err := c.getDevicesByLocationPages(ctx, session, location, func(page []Device, lastPage bool) bool {
+		devices = append(devices, page...)
+		return true
+	})
+	if err != nil {
+		return make([]Device, 0), err
 	}
 
-	// make sure you have the required environment variable
-	endpoint := os.Getenv("BUILDINGX_ENDPOINT")
-	if endpoint == "" {
-		return devices, errors.New("missing buildingx api endpoint")
+	return devices, nil
+
+}
+func (c *Client) getDevicesByLocationPages(ctx context.Context, session *Session, location *Location, fn func([]Device, bool) bool) error {
+
+	// make sure session is initialized
+	if !session.usable() {
+		return ErrSessionNotInitialized
 	}
 
 	// create the API request
 	path := fmt.Sprintf("devices?include=hasFeatures.DeviceInfo,hasFeatures.Connectivity&filter[hasLocation.data.id]=%s", location.ID)
 	req := APIRequest{
-		Partition: session.Partition,
-		JWT:       session.JWT,
+		Name:      "GetDevicesByLocation",
 		Path:      path,
 		Operation: GET,
 	}
 
-	// make the API call
-	resp, err := MakeRESTCall(req)
-	if err != nil {
-		return devices, errors.New("error making REST call: " + err.Error())
-	}
-
-	return parseDevicesJSON(resp)
+	// make the API calls, one per page
+	return c.pagedCall(ctx, session, req, func(payload []byte, lastPage bool) (bool, error) {
+		devices, err := parseDevicesJSON(payload)
+		if err != nil {
+			return false, err
+		}
+		return fn(devices, lastPage), nil
+	})
 
 }
 
 // returns an array of devices that are associated with a particular gateway
 func GetDevicesByGateway(session *Session, gatewayID string) ([]Device, error) {
+	return GetDevicesByGatewayWithContext(context.Background(), session, gatewayID)
+}
 
-	devices := make([]Device, 0)
+// GetDevicesByGatewayWithContext is like GetDevicesByGateway but uses the given context for the API call and any token refresh
+func GetDevicesByGatewayWithContext(ctx context.Context, session *Session, gatewayID string) ([]Device, error) {
+	return session.apiClient().getDevicesByGateway(ctx, session, gatewayID)
+}
 
-	// make sure session is initialized
-	if !session.IsInitialized {
-		return devices, errors.New("session is not initialized")
+// GetDevicesByGateway returns an array of devices that are associated with a particular gateway
+func (c *Client) GetDevicesByGateway(gatewayID string) ([]Device, error) {
+	return c.GetDevicesByGatewayWithContext(context.Background(), gatewayID)
+}
+
+// GetDevicesByGatewayWithContext is like GetDevicesByGateway but uses the given context for the API call and any token refresh
+func (c *Client) GetDevicesByGatewayWithContext(ctx context.Context, gatewayID string) ([]Device, error) {
+	return c.getDevicesByGateway(ctx, c.session, gatewayID)
+}
+
+// GetDevicesByGatewayPages calls fn with each page of devices that are associated with a particular gateway. Iteration stops
+// when fn returns false or the last page has been read.
+func GetDevicesByGatewayPages(session *Session, gatewayID string, fn func(devices []Device, lastPage bool) bool) error {
+	return GetDevicesByGatewayPagesWithContext(context.Background(), session, gatewayID, fn)
+}
+
+// GetDevicesByGatewayPagesWithContext is like GetDevicesByGatewayPages but uses the given context for the API calls and any token refresh
+func GetDevicesByGatewayPagesWithContext(ctx context.Context, session *Session, gatewayID string, fn func(devices []Device, lastPage bool) bool) error {
+	return session.apiClient().getDevicesByGatewayPages(ctx, session, gatewayID, fn)
+}
+
+// GetDevicesByGatewayPages calls fn with each page of devices that are associated with a particular gateway
+func (c *Client) GetDevicesByGatewayPages(gatewayID string, fn func(devices []Device, lastPage bool) bool) error {
+	return c.GetDevicesByGatewayPagesWithContext(context.Background(), gatewayID, fn)
+}
+
+// GetDevicesByGatewayPagesWithContext is like GetDevicesByGatewayPages but uses the given context for the API calls and any token refresh
+func (c *Client) GetDevicesByGatewayPagesWithContext(ctx context.Context, gatewayID string, fn func(devices []Device, lastPage bool) bool) error {
+	return c.getDevicesByGatewayPages(ctx, c.session, gatewayID, fn)
+}
+
+func (c *Client) getDevicesByGateway(ctx context.Context, session *Session, gatewayID string) ([]Device, error) {
+
+	devices := make([]Device, 0)
+	err := c.getDevicesByGatewayPages(ctx, session, gatewayID, func(page []Device, lastPage bool) bool {
+		devices = append(devices, page...)
+		return true
+	})
+	if err != nil {
+		return make([]Device, 0), err
 	}
 
-	// make sure you have the required environment variable
-	endpoint := os.Getenv("BUILDINGX_ENDPOINT")
-	if endpoint == "" {
-		return devices, errors.New("missing buildingx api endpoint")
+	return devices, nil
+
+}
+func (c *Client) getDevicesByGatewayPages(ctx context.Context, session *Session, gatewayID string, fn func([]Device, bool) bool) error {
+
+	// make sure session is initialized
+	if !session.usable() {
+		return ErrSessionNotInitialized
 	}
 
 	// create the API request
 	path := fmt.Sprintf("devices/%s/devices?include=hasFeatures.DeviceInfo", gatewayID)
 	req := APIRequest{
-		Partition: session.Partition,
-		JWT:       session.JWT,
+		Name:      "GetDevicesByGateway",
 		Path:      path,
 		Operation: GET,
 	}
 
-	// make the API call
-	resp, err := MakeRESTCall(req)
-	if err != nil {
-		return devices, errors.New("error making REST call: " + err.Error())
-	}
-
-	return parseDevicesJSON(resp)
+	// make the API calls, one per page
+	return c.pagedCall(ctx, session, req, func(payload []byte, lastPage bool) (bool, error) {
+		devices, err := parseDevicesJSON(payload)
+		if err != nil {
+			return false, err
+		}
+		return fn(devices, lastPage), nil
+	})
 
 }
 
 // returns an array of devices that are associated with the partition
 func GetAllDevices(session *Session) ([]Device, error) {
+	return GetAllDevicesWithContext(context.Background(), session)
+}
 
-	devices := make([]Device, 0)
+// GetAllDevicesWithContext is like GetAllDevices but uses the given context for the API call and any token refresh
+func GetAllDevicesWithContext(ctx context.Context, session *Session) ([]Device, error) {
+	return session.apiClient().getAllDevices(ctx, session)
+}
 
-	// make sure session is initialized
-	if !session.IsInitialized {
-		return devices, errors.New("session is not initialized")
+// GetAllDevices returns an array of devices that are associated with the client partition
+func (c *Client) GetAllDevices() ([]Device, error) {
+	return c.GetAllDevicesWithContext(context.Background())
+}
+
+// GetAllDevicesWithContext is like GetAllDevices but uses the given context for the API call and any token refresh
+func (c *Client) GetAllDevicesWithContext(ctx context.Context) ([]Device, error) {
+	return c.getAllDevices(ctx, c.session)
+}
+
+// GetAllDevicesPages calls fn with each page of devices that are associated with the partition. Iteration stops
+// when fn returns false or the last page has been read.
+func GetAllDevicesPages(session *Session, fn func(devices []Device, lastPage bool) bool) error {
+	return GetAllDevicesPagesWithContext(context.Background(), session, fn)
+}
+
+// GetAllDevicesPagesWithContext is like GetAllDevicesPages but uses the given context for the API calls and any token refresh
+func GetAllDevicesPagesWithContext(ctx context.Context, session *Session, fn func(devices []Device, lastPage bool) bool) error {
+	return session.apiClient().getAllDevicesPages(ctx, session, fn)
+}
+
+// GetAllDevicesPages calls fn with each page of devices that are associated with the client partition
+func (c *Client) GetAllDevicesPages(fn func(devices []Device, lastPage bool) bool) error {
+	return c.GetAllDevicesPagesWithContext(context.Background(), fn)
+}
+
+// GetAllDevicesPagesWithContext is like GetAllDevicesPages but uses the given context for the API calls and any token refresh
+func (c *Client) GetAllDevicesPagesWithContext(ctx context.Context, fn func(devices []Device, lastPage bool) bool) error {
+	return c.getAllDevicesPages(ctx, c.session, fn)
+}
+
+func (c *Client) getAllDevices(ctx context.Context, session *Session) ([]Device, error) {
+
+	devices := make([]Device, 0)
+	err := c.getAllDevicesPages(ctx, session, func(page []Device, lastPage bool) bool {
+		devices = append(devices, page...)
+		return true
+	})
+	if err != nil {
+		return make([]Device, 0), err
 	}
 
-	// make sure you have the required environment variable
-	endpoint := os.Getenv("BUILDINGX_ENDPOINT")
-	if endpoint == "" {
-		return devices, errors.New("missing buildingx api endpoint")
+	return devices, nil
+
+}
+func (c *Client) getAllDevicesPages(ctx context.Context, session *Session, fn func([]Device, bool) bool) error {
+
+	// make sure session is initialized
+	if !session.usable() {
+		return ErrSessionNotInitialized
 	}
 
 	// create the API request
-	path := fmt.Sprintf("devices?include=hasFeatures.DeviceInfo,hasFeatures.Connectivity")
+	path := "devices?include=hasFeatures.DeviceInfo,hasFeatures.Connectivity"
 	req := APIRequest{
-		Partition: session.Partition,
-		JWT:       session.JWT,
+		Name:      "GetAllDevices",
 		Path:      path,
 		Operation: GET,
 	}
 
-	// make the API call
-	resp, err := MakeRESTCall(req)
-	if err != nil {
-		return devices, errors.New("error making REST call: " + err.Error())
-	}
-
-	return parseDevicesJSON(resp)
+	// make the API calls, one per page
+	return c.pagedCall(ctx, session, req, func(payload []byte, lastPage bool) (bool, error) {
+		devices, err := parseDevicesJSON(payload)
+		if err != nil {
+			return false, err
+		}
+		return fn(devices, lastPage), nil
+	})
 
 }
 func parseDevicesJSON(payload []byte) ([]Device, error) {
@@ -222,32 +360,44 @@ func parseDevicesJSON(payload []byte) ([]Device, error) {
 
 // returns a single device by its id
 func GetSingleDevice(session *Session, id string) (Device, error) {
+	return GetSingleDeviceWithContext(context.Background(), session, id)
+}
+
+// GetSingleDeviceWithContext is like GetSingleDevice but uses the given context for the API call and any token refresh
+func GetSingleDeviceWithContext(ctx context.Context, session *Session, id string) (Device, error) {
+	return session.apiClient().getSingleDevice(ctx, session, id)
+}
+
+// GetSingleDevice returns a single device by its id
+func (c *Client) GetSingleDevice(id string) (Device, error) {
+	return c.GetSingleDeviceWithContext(context.Background(), id)
+}
+
+// GetSingleDeviceWithContext is like GetSingleDevice but uses the given context for the API call and any token refresh
+func (c *Client) GetSingleDeviceWithContext(ctx context.Context, id string) (Device, error) {
+	return c.getSingleDevice(ctx, c.session, id)
+}
+
+func (c *Client) getSingleDevice(ctx context.Context, session *Session, id string) (Device, error) {
 
 	device := Device{}
 	// make sure session is initialized
-	if !session.IsInitialized {
-		return device, errors.New("session is not initialized")
-	}
-
-	// make sure you have the required environment variable
-	endpoint := os.Getenv("BUILDINGX_ENDPOINT")
-	if endpoint == "" {
-		return device, errors.New("missing buildingx api endpoint")
+	if !session.usable() {
+		return device, ErrSessionNotInitialized
 	}
 
 	// create the API request
 	path := fmt.Sprintf("devices/%s", id)
 	req := APIRequest{
-		Partition: session.Partition,
-		JWT:       session.JWT,
+		Name:      "GetSingleDevice",
 		Path:      path,
 		Operation: GET,
 	}
 
 	// make the API call
-	resp, err := MakeRESTCall(req)
+	resp, err := c.call(ctx, session, req)
 	if err != nil {
-		return device, errors.New("error making REST call: " + err.Error())
+		return device, fmt.Errorf("error making REST call: %w", err)
 	}
 
 	//TODO This function has code duplication for parsing device information. consolidate with GetAllDevices, GetDevicesByLocation and GetDevicesByGateway
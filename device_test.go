@@ -2,7 +2,6 @@ package buildingx
 
 import (
 	"context"
-	"os"
 	"strings"
 	"testing"
 
@@ -14,11 +13,8 @@ func TestGetDevicesByLocation(t *testing.T) {
 	ctx := context.Background()
 	ctx, _ = xray.BeginSegment(ctx, "TestGetDevicesByLocation")
 
-	// first make sure you have a partition ID (from environment variable)
-	partitionID := os.Getenv("BUILDINGX_PARTITION_ID")
-	if partitionID == "" {
-		t.Fatal("unable to find partition ID in environment variable")
-	}
+	// first make sure you have a partition ID and credentials (the test is skipped without them)
+	partitionID := integrationPartition(t)
 
 	// initialize the session (uses credentials to authenticate and produce a JWT)
 	session := Session{}
@@ -63,11 +59,8 @@ func TestGetDevicesByGateway(t *testing.T) {
 	ctx := context.Background()
 	ctx, _ = xray.BeginSegment(ctx, "TestGetDevicesByGateway")
 
-	// first make sure you have a partition ID (from environment variable)
-	partitionID := os.Getenv("BUILDINGX_PARTITION_ID")
-	if partitionID == "" {
-		t.Fatal("unable to find partition ID in environment variable")
-	}
+	// first make sure you have a partition ID and credentials (the test is skipped without them)
+	partitionID := integrationPartition(t)
 
 	// initialize the session (uses credentials to authenticate and produce a JWT)
 	session := Session{}
@@ -119,11 +112,8 @@ func TestGetSingleDevice(t *testing.T) {
 	ctx := context.Background()
 	ctx, _ = xray.BeginSegment(ctx, "TestGetSingleDevice")
 
-	// first make sure you have a partition ID (from environment variable)
-	partitionID := os.Getenv("BUILDINGX_PARTITION_ID")
-	if partitionID == "" {
-		t.Fatal("unable to find partition ID in environment variable")
-	}
+	// first make sure you have a partition ID and credentials (the test is skipped without them)
+	partitionID := integrationPartition(t)
 
 	// initialize the session (uses credentials to authenticate and produce a JWT)
 	session := Session{}
@@ -0,0 +1,105 @@
+package buildingx
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+)
+
+// ErrDryRun matches the error returned by a write that was not sent because dry-run mode is on
+var ErrDryRun = errors.New("dry run")
+
+// DryRunError is returned by a write (ex: CommandPointValue) in dry-run mode instead of calling the API. It holds
+// the exact request that would have been sent.
+type DryRunError struct {
+	// Name is the operation that made the write (ex: CommandPointValue)
+	Name   string
+	Method string
+	// Path is relative to the partition (ex: points/{id}?field[Point]=pointValue)
+	Path string
+	URL  string
+	// Body is the JSON payload (ex: the SBPointCommand)
+	Body []byte
+}
+
+func (e *DryRunError) Error() string {
+	return fmt.Sprintf("dry run: %s %s %s", e.Method, e.URL, e.Body)
+}
+
+// Is makes errors.Is(err, ErrDryRun) true for every DryRunError
+func (e *DryRunError) Is(target error) bool {
+	return target == ErrDryRun
+}
+
+// WithDryRun turns on dry-run mode for the client. Reads are made normally, but writes are logged to logger
+// (or the standard logger when nil) and return a DryRunError holding the request instead of calling the API.
+func WithDryRun(logger *log.Logger) Option {
+	return func(c *Config) { c.DryRun, c.DryRunLogger = true, logger }
+}
+
+// SetDryRun turns dry-run mode on or off for the session. In dry-run mode writes are logged (to the standard
+// logger unless SetDryRunLogger sets another) and return a DryRunError instead of calling the API. Invalidating
+// or initializing the session again does not change the setting.
+func (t *Session) SetDryRun(enabled bool) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.dryRun = enabled
+
+}
+
+// SetDryRunLogger sets the logger the writes of the session are logged to in dry-run mode. A nil logger
+// restores the standard logger.
+func (t *Session) SetDryRunLogger(logger *log.Logger) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.dryRunLog = logger
+
+}
+
+// DryRun reports whether dry-run mode was turned on for the session with SetDryRun
+func (t *Session) DryRun() bool {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.dryRun
+
+}
+
+// dryRunWrite logs the write the client would make for the session and returns it as a DryRunError, or
+// returns nil when neither the client nor the session is in dry-run mode
+func (c *Client) dryRunWrite(session *Session, apiReq APIRequest) error {
+
+	if !c.config.DryRun && !session.DryRun() {
+		return nil
+	}
+
+	body, _ := ioutil.ReadAll(&apiReq.Body)
+	dryRunErr := &DryRunError{
+		Name:   apiReq.Name,
+		Method: string(apiReq.Operation),
+		Path:   apiReq.Path,
+		URL:    fmt.Sprintf("%s/operations/partitions/%s/%s", c.config.Endpoint, session.PartitionID(), apiReq.Path),
+		Body:   body,
+	}
+
+	// the logger of the client is used when the client is in dry-run mode, and the one of the session otherwise
+	logger := c.config.DryRunLogger
+	if !c.config.DryRun {
+		session.mu.RLock()
+		logger = session.dryRunLog
+		session.mu.RUnlock()
+	}
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Printf("buildingx: dry run of %s: %s %s %s", dryRunErr.Name, dryRunErr.Method, dryRunErr.URL, dryRunErr.Body)
+
+	return dryRunErr
+
+}
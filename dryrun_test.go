@@ -0,0 +1,83 @@
+package buildingx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRun(t *testing.T) {
+
+	writes, reads := 0, 0
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			writes++
+			return
+		}
+		reads++
+		fmt.Fprint(w, `{"data":{"id":"fan","attributes":{"dataType":"boolean","pointValue":{"value":"false"}}}}`)
+	}))
+	fan := &Point{ID: "fan", DataType: "boolean", Writable: true}
+
+	t.Run("client-writes-are-logged-and-returned", func(t *testing.T) {
+		logs := bytes.Buffer{}
+		client, err := NewClient(config, WithDryRun(log.New(&logs, "", 0)))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		err = client.CommandPointValue(fan, "true", Priority(8))
+		var dryRunErr *DryRunError
+		if !errors.As(err, &dryRunErr) {
+			t.Fatal("expected a DryRunError but got: ", err)
+		}
+		assert.True(t, errors.Is(err, ErrDryRun))
+		assert.Equal(t, "CommandPointValue", dryRunErr.Name)
+		assert.Equal(t, "PATCH", dryRunErr.Method)
+		assert.Equal(t, "points/fan?field[Point]=pointValue", dryRunErr.Path)
+		assert.Equal(t, config.Endpoint+"/operations/partitions/partition/points/fan?field[Point]=pointValue", dryRunErr.URL)
+		assert.Equal(t, `{"data":{"id":"fan","attributes":{"pointValue":{"value":"true","priority":8}}}}`, string(dryRunErr.Body))
+		assert.Contains(t, logs.String(), "dry run of CommandPointValue: PATCH")
+
+		assert.True(t, errors.Is(client.ReleasePointCommand(fan), ErrDryRun))
+		point, err := client.GetSinglePoint("fan")
+		assert.Nil(t, err)
+		assert.Equal(t, "false", point.StringValue)
+		assert.Equal(t, 0, writes)
+		assert.Equal(t, 1, reads)
+	})
+	t.Run("session-dry-run", func(t *testing.T) {
+		client, err := NewClient(config)
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+		logs := bytes.Buffer{}
+		session := client.Session()
+		session.SetDryRun(true)
+		session.SetDryRunLogger(log.New(&logs, "", 0))
+		assert.True(t, session.DryRun())
+		assert.True(t, errors.Is(CommandPointValue(session, fan, "true"), ErrDryRun))
+		assert.Equal(t, 0, writes)
+		assert.Contains(t, logs.String(), "dry run of CommandPointValue: PATCH")
+
+		session.SetDryRun(false)
+		assert.Nil(t, CommandPointValue(session, fan, "true"))
+		assert.Equal(t, 1, writes)
+	})
+	t.Run("batch-results", func(t *testing.T) {
+		client, err := NewClient(config, WithDryRun(log.New(&bytes.Buffer{}, "", 0)))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+		results, err := client.CommandPoints([]PointCommand{{Point: fan, Value: "true"}}, StopOnError())
+		assert.Nil(t, err)
+		assert.Equal(t, CommandDryRun, results[0].Outcome)
+		assert.True(t, errors.Is(results[0].Err, ErrDryRun))
+	})
+
+}
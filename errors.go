@@ -0,0 +1,140 @@
+package buildingx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrSessionNotInitialized is returned when a call is made with a session that has no token and no way to obtain one
+	ErrSessionNotInitialized = errors.New("session is not initialized")
+	// ErrMissingConfig is matched by every error caused by a missing configuration setting or environment variable
+	ErrMissingConfig = errors.New("missing configuration")
+
+	// ErrUnauthorized is matched by an APIError with a 401 status
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is matched by an APIError with a 403 status
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound is matched by an APIError with a 404 status
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited is matched by an APIError with a 429 status
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// ConfigError reports a configuration setting that is missing. It matches ErrMissingConfig.
+type ConfigError struct {
+	Setting string
+}
+
+func (e *ConfigError) Error() string {
+	return "missing " + e.Setting
+}
+
+// Is makes errors.Is(err, ErrMissingConfig) true for every ConfigError
+func (e *ConfigError) Is(target error) bool {
+	return target == ErrMissingConfig
+}
+
+// APIError is returned when Building X responds with a status code other than 200 or 204. It keeps every
+// error entry of the response so that callers never need to inspect the error text.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Path       string
+	Errors     []SBErrorResponse
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+
+	if len(e.Errors) < 1 {
+		return fmt.Sprintf("the building x API returned a status code of %s but no error message was included", e.Status)
+	}
+	return fmt.Sprintf("the building x API returned a status code of %s and an error detail message as follows: %s", e.Status, e.Errors[0].Detail)
+
+}
+
+// Is matches the status sentinel errors (ErrUnauthorized, ErrForbidden, ErrNotFound and ErrRateLimited)
+func (e *APIError) Is(target error) bool {
+
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == 401
+	case ErrForbidden:
+		return e.StatusCode == 403
+	case ErrNotFound:
+		return e.StatusCode == 404
+	case ErrRateLimited:
+		return e.StatusCode == 429
+	}
+	return false
+
+}
+
+// Retryable reports whether the status code indicates a transient condition
+func (e *APIError) Retryable() bool {
+
+	switch e.StatusCode {
+	case 408, 429, 500, 502, 503, 504:
+		return true
+	}
+	return false
+
+}
+
+// IsNotFound reports whether err was caused by a 404 response
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsUnauthorized reports whether err was caused by a 401 response
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsForbidden reports whether err was caused by a 403 response
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbidden)
+}
+
+// IsRateLimited reports whether err was caused by a 429 response
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsRetryable reports whether the call that produced err may succeed if it is made again. This is true for
+// transient status codes and for network failures that are likely to be transient: timeouts and connections
+// that were refused, reset or closed before the response. It is false when the context was canceled or its
+// deadline passed, and for other network errors such as certificate failures or unsupported URLs.
+func IsRetryable(err error) bool {
+
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+
+	return isTransientNetworkError(err)
+
+}
+
+// isTransientNetworkError reports whether err is a network timeout or a connection that was refused, reset
+// or closed by the server before it responded (which is how a dropped keep-alive connection shows up)
+func isTransientNetworkError(err error) bool {
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+
+}
@@ -0,0 +1,110 @@
+package buildingx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIError(t *testing.T) {
+
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/operations/partitions/partition/points/missing":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[{"id":"e-1","code":"NOT_FOUND","status":"404","title":"Not Found","detail":"point not found"},{"id":"e-2","detail":"second"}]}`)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `not json`)
+		}
+	}))
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+
+	t.Run("not-found-keeps-every-error-entry", func(t *testing.T) {
+		_, err := client.GetSinglePoint("missing")
+		assert.True(t, IsNotFound(err))
+		assert.False(t, IsRetryable(err))
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatal("expected an APIError but got: ", err)
+		}
+		assert.Equal(t, 404, apiErr.StatusCode)
+		assert.Equal(t, "points/missing?field[Point]=pointValue", apiErr.Path)
+		assert.Equal(t, 2, len(apiErr.Errors))
+		assert.Equal(t, "NOT_FOUND", apiErr.Errors[0].Code)
+	})
+	t.Run("unparsable-error-body-keeps-status", func(t *testing.T) {
+		_, err := client.GetAllDevices()
+		assert.True(t, IsRetryable(err))
+		assert.False(t, IsNotFound(err))
+	})
+	t.Run("canceled-context-is-not-retryable", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := client.GetAllDevicesWithContext(ctx)
+		assert.NotNil(t, err)
+		assert.False(t, IsRetryable(err))
+	})
+	t.Run("uninitialized-session", func(t *testing.T) {
+		_, err := GetLocations(&Session{})
+		assert.True(t, errors.Is(err, ErrSessionNotInitialized))
+	})
+	t.Run("missing-configuration", func(t *testing.T) {
+		_, err := NewClient(config, WithEndpoint(""))
+		assert.True(t, errors.Is(err, ErrMissingConfig))
+	})
+
+}
+
+func TestIsRetryable(t *testing.T) {
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	secure := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	secure.Config.ErrorLog = log.New(io.Discard, "", 0)
+	secure.StartTLS()
+	defer secure.Close()
+	refusing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	refusing.Close()
+
+	get := func(client *http.Client, ctx context.Context, url string) error {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			t.Fatal("error creating request: ", err.Error())
+		}
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	t.Run("refused-connection-is-retryable", func(t *testing.T) {
+		assert.True(t, IsRetryable(get(http.DefaultClient, context.Background(), refusing.URL)))
+	})
+	t.Run("deadline-is-not-retryable", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.False(t, IsRetryable(get(http.DefaultClient, ctx, slow.URL)))
+	})
+	t.Run("other-network-errors-are-not-retryable", func(t *testing.T) {
+		// the certificate of the test server is not trusted by the default client
+		assert.False(t, IsRetryable(get(http.DefaultClient, context.Background(), secure.URL)))
+		assert.False(t, IsRetryable(get(http.DefaultClient, context.Background(), "ftp://127.0.0.1/")))
+	})
+
+}
@@ -0,0 +1,38 @@
+package buildingx_test
+
+import (
+	"testing"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+	"github.com/cloudlinesolutions/buildingx-operations-api/buildingxtest"
+)
+
+// newSite returns a fake serving the default fixtures and a client of it. Unlike the tests of package buildingx,
+// which serve canned responses with httptest, the tests of this package run against the buildingxtest fake.
+func newSite(t *testing.T, opts ...buildingx.Option) (*buildingxtest.Server, *buildingx.Client) {
+	return newFake(t, buildingxtest.DefaultFixtures(), opts...)
+}
+
+// newFake returns a fake serving the fixtures and a client of it
+func newFake(t *testing.T, fixtures buildingxtest.Fixtures, opts ...buildingx.Option) (*buildingxtest.Server, *buildingx.Client) {
+
+	server := buildingxtest.NewServer(fixtures)
+	t.Cleanup(server.Close)
+	client, err := server.Client(opts...)
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+	return server, client
+
+}
+
+// sitePoint reads a point of the fake
+func sitePoint(t *testing.T, client *buildingx.Client, id string) *buildingx.Point {
+
+	point, err := client.GetSinglePoint(id)
+	if err != nil {
+		t.Fatal("error getting point: ", err.Error())
+	}
+	return &point
+
+}
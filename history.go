@@ -0,0 +1,274 @@
+package buildingx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	// defaultHistoryChunk is the longest time range requested at once by the history records functions
+	defaultHistoryChunk = 7 * 24 * time.Hour
+	// historyBuffer is the number of records an iterator reads ahead of the caller
+	historyBuffer = 500
+)
+
+// HistoryRecord is a value of a point recorded at a point in time
+type HistoryRecord struct {
+	Time time.Time
+	// Value is the recorded value decoded according to the data type of the point. When the raw value does not
+	// match the data type (ex: a status text recorded for a numeric point), Value is a string value holding Raw.
+	Value Value
+	// Raw is the value as returned by the API
+	Raw string
+}
+
+// HistoryOption changes how the history of a point is read
+type HistoryOption func(*historyOptions)
+
+type historyOptions struct {
+	chunk    time.Duration
+	pageSize int
+}
+
+// HistoryChunk sets the longest time range requested from the API at once. Longer ranges are split into
+// consecutive chunks that are read in order. It defaults to 7 days; zero or less reads the range at once.
+func HistoryChunk(d time.Duration) HistoryOption {
+	return func(o *historyOptions) { o.chunk = d }
+}
+
+// HistoryPageSize sets the number of records requested per page. The API default applies when it is not set.
+func HistoryPageSize(n int) HistoryOption {
+	return func(o *historyOptions) { o.pageSize = n }
+}
+
+// GetPointHistoryRecords returns the values of a point recorded from start to end, both included, with
+// parsed timestamps and typed values. The range is read in chunks and every page of each chunk is followed.
+func GetPointHistoryRecords(session *Session, point *Point, start, end time.Time, opts ...HistoryOption) ([]HistoryRecord, error) {
+	return GetPointHistoryRecordsWithContext(context.Background(), session, point, start, end, opts...)
+}
+
+// GetPointHistoryRecordsWithContext is like GetPointHistoryRecords but uses the given context for the API calls and any token refresh
+func GetPointHistoryRecordsWithContext(ctx context.Context, session *Session, point *Point, start, end time.Time, opts ...HistoryOption) ([]HistoryRecord, error) {
+	return session.apiClient().getPointHistoryRecords(ctx, session, point, start, end, opts...)
+}
+
+// GetPointHistoryRecords returns the values of a point recorded from start to end with parsed timestamps and typed values
+func (c *Client) GetPointHistoryRecords(point *Point, start, end time.Time, opts ...HistoryOption) ([]HistoryRecord, error) {
+	return c.GetPointHistoryRecordsWithContext(context.Background(), point, start, end, opts...)
+}
+
+// GetPointHistoryRecordsWithContext is like GetPointHistoryRecords but uses the given context for the API calls and any token refresh
+func (c *Client) GetPointHistoryRecordsWithContext(ctx context.Context, point *Point, start, end time.Time, opts ...HistoryOption) ([]HistoryRecord, error) {
+	return c.getPointHistoryRecords(ctx, c.session, point, start, end, opts...)
+}
+
+func (c *Client) getPointHistoryRecords(ctx context.Context, session *Session, point *Point, start, end time.Time, opts ...HistoryOption) ([]HistoryRecord, error) {
+
+	records := make([]HistoryRecord, 0)
+	it := c.streamPointHistory(ctx, session, point, start, end, opts...)
+	defer it.Close()
+	for it.Next() {
+		records = append(records, it.Record())
+	}
+	if err := it.Err(); err != nil {
+		return make([]HistoryRecord, 0), err
+	}
+
+	return records, nil
+
+}
+
+// StreamPointHistory returns an iterator over the values of a point recorded from start to end, so that long
+// ranges (ex: several months) can be processed without holding every record in memory. Pages are read in the
+// background, a little ahead of the caller. The iterator must be closed when the caller stops early.
+//
+//	it := StreamPointHistory(session, &point, start, end)
+//	defer it.Close()
+//	for it.Next() {
+//		record := it.Record()
+//	}
+//	if err := it.Err(); err != nil {
+//		// handle the error
+//	}
+func StreamPointHistory(session *Session, point *Point, start, end time.Time, opts ...HistoryOption) *HistoryIterator {
+	return StreamPointHistoryWithContext(context.Background(), session, point, start, end, opts...)
+}
+
+// StreamPointHistoryWithContext is like StreamPointHistory but uses the given context for the API calls and any token refresh
+func StreamPointHistoryWithContext(ctx context.Context, session *Session, point *Point, start, end time.Time, opts ...HistoryOption) *HistoryIterator {
+	return session.apiClient().streamPointHistory(ctx, session, point, start, end, opts...)
+}
+
+// StreamPointHistory returns an iterator over the values of a point recorded from start to end
+func (c *Client) StreamPointHistory(point *Point, start, end time.Time, opts ...HistoryOption) *HistoryIterator {
+	return c.StreamPointHistoryWithContext(context.Background(), point, start, end, opts...)
+}
+
+// StreamPointHistoryWithContext is like StreamPointHistory but uses the given context for the API calls and any token refresh
+func (c *Client) StreamPointHistoryWithContext(ctx context.Context, point *Point, start, end time.Time, opts ...HistoryOption) *HistoryIterator {
+	return c.streamPointHistory(ctx, c.session, point, start, end, opts...)
+}
+
+// HistoryIterator reads the history of a point one record at a time. It is not safe for use by many goroutines.
+type HistoryIterator struct {
+	records chan HistoryRecord
+	cancel  context.CancelFunc
+	record  HistoryRecord
+	err     error
+}
+
+// Next advances to the next record and reports whether there is one. When it returns false, Err tells
+// whether the iteration completed or failed.
+func (it *HistoryIterator) Next() bool {
+
+	record, ok := <-it.records
+	if ok {
+		it.record = record
+	}
+	return ok
+
+}
+
+// Record returns the current record
+func (it *HistoryIterator) Record() HistoryRecord {
+	return it.record
+}
+
+// Err returns the error that ended the iteration, if any. It must only be called after Next returned false.
+func (it *HistoryIterator) Err() error {
+	return it.err
+}
+
+// Close stops reading the history. It is safe to call Close more than once and after the iteration ended.
+func (it *HistoryIterator) Close() {
+
+	it.cancel()
+	for range it.records {
+	}
+
+}
+
+func (c *Client) streamPointHistory(ctx context.Context, session *Session, point *Point, start, end time.Time, opts ...HistoryOption) *HistoryIterator {
+
+	options := historyOptions{chunk: defaultHistoryChunk}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	it := &HistoryIterator{records: make(chan HistoryRecord, historyBuffer), cancel: cancel}
+
+	go func() {
+		// err is written before the channel is closed, so it is visible once Next returns false
+		defer close(it.records)
+		it.err = c.readPointHistory(ctx, session, point, start, end, options, func(record HistoryRecord) bool {
+			select {
+			case it.records <- record:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		// an iterator closed by the caller ends without error, but not one whose context was cancelled
+		if errors.Is(it.err, context.Canceled) && parent.Err() == nil {
+			it.err = nil
+		}
+		if it.err == nil && parent.Err() != nil {
+			it.err = parent.Err()
+		}
+	}()
+
+	return it
+
+}
+
+// readPointHistory reads the range one chunk at a time and hands each record to fn, which returns false to stop
+func (c *Client) readPointHistory(ctx context.Context, session *Session, point *Point, start, end time.Time, options historyOptions, fn func(HistoryRecord) bool) error {
+
+	// bound counts the raw values of the records emitted at the end bound of the previous chunk, which is the
+	// start bound of the next one
+	var bound map[string]int
+
+	for from := start; !from.After(end); {
+
+		to := end
+		if options.chunk > 0 && to.Sub(from) > options.chunk {
+			to = from.Add(options.chunk)
+		}
+
+		more := true
+		seen, next := bound, make(map[string]int)
+		req := APIRequest{
+			Name:      "GetPointHistory",
+			Path:      pointHistoryPath(point.ID, from, to, options.pageSize),
+			Operation: GET,
+		}
+		err := c.pagedCall(ctx, session, req, func(payload []byte, lastPage bool) (bool, error) {
+			history, err := parsePointHistoryJSON(payload)
+			if err != nil {
+				return false, err
+			}
+			for _, h := range history {
+				record, err := newHistoryRecord(point.DataType, h)
+				if err != nil {
+					return false, err
+				}
+				// chunks share their bounds, so a record at a bound is returned by both chunks. Only that repeat
+				// is skipped: records are not assumed to be in order and several may share a timestamp.
+				if record.Time.Equal(from) && seen[record.Raw] > 0 {
+					seen[record.Raw]--
+					continue
+				}
+				if more = fn(record); !more {
+					return false, nil
+				}
+				if record.Time.Equal(to) {
+					next[record.Raw]++
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+		if !more || !to.Before(end) {
+			return nil
+		}
+		from, bound = to, next
+
+	}
+
+	return nil
+
+}
+
+// pointHistoryPath returns the path that reads the history of a point from start to end. The bounds are
+// escaped, since a + in a time zone offset would otherwise be read as a space.
+func pointHistoryPath(id string, start, end time.Time, pageSize int) string {
+
+	path := fmt.Sprintf("points/%s/values?filter[timestamp][from]=%s&filter[timestamp][to]=%s", id,
+		url.QueryEscape(start.Format(time.RFC3339Nano)), url.QueryEscape(end.Format(time.RFC3339Nano)))
+	if pageSize > 0 {
+		path += fmt.Sprintf("&page[size]=%d", pageSize)
+	}
+	return path
+
+}
+
+func newHistoryRecord(dataType string, h PointHistory) (HistoryRecord, error) {
+
+	timestamp, err := time.Parse(time.RFC3339Nano, h.Timestamp)
+	if err != nil {
+		return HistoryRecord{}, fmt.Errorf("unable to parse history timestamp %q: %w", h.Timestamp, err)
+	}
+	value, err := ParseValue(dataType, h.Value)
+	if err != nil {
+		value = TextValue(h.Value)
+	}
+	return HistoryRecord{Time: timestamp, Value: value, Raw: h.Value}, nil
+
+}
@@ -0,0 +1,230 @@
+package buildingx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// historyStart is when the first record of newHistorian is recorded
+var historyStart = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newHistorian returns a client for an API with a numeric point that recorded the given number of values, one
+// every 6 hours from historyStart. The history is filtered by both timestamp bounds and paginated. The returned
+// function lists the time ranges requested.
+func newHistorian(t *testing.T, count int) (*Client, func() []string) {
+
+	mu := sync.Mutex{}
+	ranges := make([]string, 0)
+
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		from, err := time.Parse(time.RFC3339Nano, query.Get("filter[timestamp][from]"))
+		if err != nil {
+			t.Error("error parsing from: ", err.Error())
+		}
+		to, err := time.Parse(time.RFC3339Nano, query.Get("filter[timestamp][to]"))
+		if err != nil {
+			t.Error("error parsing to: ", err.Error())
+		}
+		size, _ := strconv.Atoi(query.Get("page[size]"))
+		if size == 0 {
+			size = 10
+		}
+		offset, _ := strconv.Atoi(query.Get("page[offset]"))
+		if offset == 0 {
+			mu.Lock()
+			ranges = append(ranges, from.UTC().Format("01-02")+"/"+to.UTC().Format("01-02"))
+			mu.Unlock()
+		}
+
+		data := make([]string, 0)
+		for i := 0; i < count; i++ {
+			timestamp := historyStart.Add(time.Duration(i) * 6 * time.Hour)
+			if timestamp.Before(from) || timestamp.After(to) {
+				continue
+			}
+			data = append(data, fmt.Sprintf(`{"attributes":{"value":"%d","timestamp":"%s"}}`, 60+i%20, timestamp.Format(time.RFC3339)))
+		}
+		next := ""
+		if offset+size < len(data) {
+			query.Set("page[offset]", strconv.Itoa(offset+size))
+			next = "points/sensor/values?" + query.Encode()
+		}
+		if offset > len(data) {
+			offset = len(data)
+		}
+		end := offset + size
+		if end > len(data) {
+			end = len(data)
+		}
+		fmt.Fprintf(w, `{"data":[%s],"links":{"next":"%s"}}`, strings.Join(data[offset:end], ","), next)
+	}))
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+
+	requested := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ranges...)
+	}
+	return client, requested
+
+}
+
+func TestPointHistoryRecords(t *testing.T) {
+
+	sensor := &Point{ID: "sensor", DataType: "number"}
+
+	t.Run("parsed-records", func(t *testing.T) {
+		client, _ := newHistorian(t, 8)
+		records, err := client.GetPointHistoryRecords(sensor, historyStart.Add(6*time.Hour), historyStart.Add(18*time.Hour))
+		if err != nil {
+			t.Fatal("error getting history: ", err.Error())
+		}
+		assert.Equal(t, 3, len(records))
+		assert.Equal(t, historyStart.Add(6*time.Hour), records[0].Time)
+		assert.Equal(t, NumberValue(61), records[0].Value)
+		assert.Equal(t, "61", records[0].Raw)
+		assert.Equal(t, historyStart.Add(18*time.Hour), records[2].Time)
+	})
+	t.Run("offsets-are-escaped", func(t *testing.T) {
+		client, _ := newHistorian(t, 8)
+		east := time.FixedZone("UTC+2", 2*60*60)
+		records, err := client.GetPointHistoryRecords(sensor, historyStart.In(east), historyStart.Add(6*time.Hour).In(east))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(records))
+		assert.Equal(t, "points/x/values?filter[timestamp][from]=2022-01-01T02%3A00%3A00%2B02%3A00&filter[timestamp][to]=2022-01-01T02%3A00%3A00%2B02%3A00&page[size]=5",
+			pointHistoryPath("x", historyStart.In(east), historyStart.In(east), 5))
+	})
+	t.Run("chunks-and-pages", func(t *testing.T) {
+		client, requested := newHistorian(t, 4*20)
+		records, err := client.GetPointHistoryRecords(sensor, historyStart, historyStart.Add(20*24*time.Hour), HistoryPageSize(7))
+		if err != nil {
+			t.Fatal("error getting history: ", err.Error())
+		}
+		assert.Equal(t, []string{"01-01/01-08", "01-08/01-15", "01-15/01-21"}, requested())
+		assert.Equal(t, 80, len(records))
+		for i := 1; i < len(records); i++ {
+			assert.Equal(t, 6*time.Hour, records[i].Time.Sub(records[i-1].Time))
+		}
+
+		client, requested = newHistorian(t, 4*20)
+		records, err = client.GetPointHistoryRecords(sensor, historyStart, historyStart.Add(20*24*time.Hour), HistoryChunk(0))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"01-01/01-21"}, requested())
+		assert.Equal(t, 80, len(records))
+	})
+	t.Run("out-of-order-and-same-time", func(t *testing.T) {
+		// newest first, with two changes of value at noon, one of which is on the bound between two chunks
+		noon := historyStart.Add(12 * time.Hour)
+		recorded := []PointHistory{
+			{Value: "64", Timestamp: noon.Add(time.Hour).Format(time.RFC3339)},
+			{Value: "63", Timestamp: noon.Format(time.RFC3339)},
+			{Value: "62", Timestamp: noon.Format(time.RFC3339)},
+			{Value: "61", Timestamp: historyStart.Format(time.RFC3339)},
+		}
+		config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			from, _ := time.Parse(time.RFC3339Nano, r.URL.Query().Get("filter[timestamp][from]"))
+			to, _ := time.Parse(time.RFC3339Nano, r.URL.Query().Get("filter[timestamp][to]"))
+			data := make([]string, 0)
+			for _, h := range recorded {
+				timestamp, _ := time.Parse(time.RFC3339, h.Timestamp)
+				if !timestamp.Before(from) && !timestamp.After(to) {
+					data = append(data, fmt.Sprintf(`{"attributes":{"value":"%s","timestamp":"%s"}}`, h.Value, h.Timestamp))
+				}
+			}
+			fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(data, ","))
+		}))
+		client, err := NewClient(config)
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		raw := func(records []HistoryRecord) []string {
+			values := make([]string, len(records))
+			for i, record := range records {
+				values[i] = record.Raw
+			}
+			return values
+		}
+		records, err := client.GetPointHistoryRecords(sensor, historyStart, historyStart.Add(24*time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"64", "63", "62", "61"}, raw(records))
+
+		records, err = client.GetPointHistoryRecords(sensor, historyStart, historyStart.Add(24*time.Hour), HistoryChunk(12*time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"63", "62", "61", "64"}, raw(records))
+	})
+	t.Run("mismatched-value", func(t *testing.T) {
+		record, err := newHistoryRecord("number", PointHistory{Value: "fault", Timestamp: "2022-01-01T00:00:00.5Z"})
+		assert.Nil(t, err)
+		assert.Equal(t, TextValue("fault"), record.Value)
+		assert.Equal(t, 500*time.Millisecond, record.Time.Sub(historyStart))
+		_, err = newHistoryRecord("number", PointHistory{Value: "1", Timestamp: "yesterday"})
+		assert.NotNil(t, err)
+	})
+
+}
+
+func TestStreamPointHistory(t *testing.T) {
+
+	sensor := &Point{ID: "sensor", DataType: "number"}
+
+	t.Run("stream", func(t *testing.T) {
+		client, _ := newHistorian(t, 4*60)
+		it := client.StreamPointHistory(sensor, historyStart, historyStart.Add(60*24*time.Hour))
+		defer it.Close()
+		count := 0
+		for it.Next() {
+			assert.Equal(t, historyStart.Add(time.Duration(count)*6*time.Hour), it.Record().Time)
+			count++
+		}
+		assert.Nil(t, it.Err())
+		assert.Equal(t, 240, count)
+	})
+	t.Run("closed-early", func(t *testing.T) {
+		client, _ := newHistorian(t, 4*60)
+		it := client.StreamPointHistory(sensor, historyStart, historyStart.Add(60*24*time.Hour), HistoryPageSize(2))
+		assert.True(t, it.Next())
+		it.Close()
+		assert.False(t, it.Next())
+		assert.Nil(t, it.Err())
+		it.Close()
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		client, _ := newHistorian(t, 4*60)
+		ctx, cancel := context.WithCancel(context.Background())
+		it := client.StreamPointHistoryWithContext(ctx, sensor, historyStart, historyStart.Add(60*24*time.Hour), HistoryPageSize(2))
+		defer it.Close()
+		assert.True(t, it.Next())
+		cancel()
+		for it.Next() {
+		}
+		assert.True(t, errors.Is(it.Err(), context.Canceled))
+	})
+	t.Run("api-error", func(t *testing.T) {
+		config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(404)
+		}))
+		client, err := NewClient(config)
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+		it := client.StreamPointHistory(sensor, historyStart, historyStart.Add(time.Hour))
+		defer it.Close()
+		assert.False(t, it.Next())
+		assert.True(t, IsNotFound(it.Err()))
+	})
+
+}
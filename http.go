@@ -2,13 +2,11 @@ package buildingx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"os"
-	"time"
 )
 
 type Verb string
@@ -20,6 +18,7 @@ const (
 )
 
 type APIRequest struct {
+	Name      string
 	Partition string
 	JWT       string
 	Path      string
@@ -39,18 +38,42 @@ type SBErrorResponse struct {
 
 // MakeRESTCall encapsulates a REST API call and returns the results of the call
 func MakeRESTCall(apiReq APIRequest) ([]byte, error) {
+	return MakeRESTCallWithContext(context.Background(), apiReq)
+}
+
+// MakeRESTCallWithContext is like MakeRESTCall but the request is bound to the given context
+func MakeRESTCallWithContext(ctx context.Context, apiReq APIRequest) ([]byte, error) {
+
+	result, _, err := envClient().makeRESTCall(ctx, apiReq)
+	return result, err
+
+}
+
+// makeRESTCall performs the REST API call and also returns the HTTP status code so that callers can react to it
+func (c *Client) makeRESTCall(ctx context.Context, apiReq APIRequest) ([]byte, int, error) {
+
+	ctx, done := c.startCall(ctx, CallEvent{Partition: apiReq.Partition, Method: string(apiReq.Operation), Path: apiReq.Path})
+	result, status, err := c.doRESTCall(ctx, apiReq)
+	done(status, err)
+	return result, status, err
+
+}
+
+func (c *Client) doRESTCall(ctx context.Context, apiReq APIRequest) ([]byte, int, error) {
 
 	result := make([]byte, 0)
 
-	endpoint := os.Getenv("BUILDINGX_ENDPOINT")
+	endpoint := c.config.Endpoint
 	if endpoint == "" {
-		return result, errors.New("missing buildingx api endpoint")
+		return result, 0, &ConfigError{Setting: "buildingx api endpoint"}
 	}
 
 	url := fmt.Sprintf("%s/operations/partitions/%s/%s", endpoint, apiReq.Partition, apiReq.Path)
 	auth := fmt.Sprintf("Bearer %s", apiReq.JWT)
-	client := &http.Client{Timeout: time.Duration(20) * time.Second}
-	req, _ := http.NewRequest(string(apiReq.Operation), url, &apiReq.Body)
+	req, err := http.NewRequestWithContext(ctx, string(apiReq.Operation), url, &apiReq.Body)
+	if err != nil {
+		return result, 0, err
+	}
 	req.Header.Add("accept", "application/json")
 	req.Header.Add("Authorization", auth)
 
@@ -60,32 +83,36 @@ func MakeRESTCall(apiReq APIRequest) ([]byte, error) {
 		req.Header.Add("content-type", "application/json")
 	}
 
-	resp, err := client.Do(req)
+	resp, err := c.httpClientFor(ctx).Do(req)
 	if err != nil {
-		return result, fmt.Errorf("unexpected error while invoking http client: %s", err.Error())
+		return result, 0, fmt.Errorf("unexpected error while invoking http client: %w", err)
 	}
 	defer resp.Body.Close()
+	c.annotateCall(ctx, apiReq.Path, resp.StatusCode)
 
 	if resp.StatusCode != 200 && resp.StatusCode != 204 {
-		// attempt to parse the error response message
-		errorResponse, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return result, fmt.Errorf("unexpected error trying to read API error response message: %s", err.Error())
-		}
-		sbResponse := SBResponse{}
-		if err := json.Unmarshal(errorResponse, &sbResponse); err != nil {
-			return result, fmt.Errorf("Error parsing API response: %s", err.Error())
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Path:       apiReq.Path,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
 		}
-		if len(sbResponse.Errors) < 1 {
-			return result, fmt.Errorf("the building x API returned a status code of %s but no error message was included", resp.Status)
+
+		// attempt to parse the error response message. When it cannot be parsed the status code is still reported.
+		errorResponse, err := ioutil.ReadAll(resp.Body)
+		if err == nil {
+			sbResponse := SBResponse{}
+			if err := json.Unmarshal(errorResponse, &sbResponse); err == nil {
+				apiErr.Errors = sbResponse.Errors
+			}
 		}
 
-		// the error response message was successfully parsed, so use it to construct an error message
-		return result, fmt.Errorf("the building x API returned a status code of %s and an error detail message as follows: %s", sbResponse.Errors[0].Status, sbResponse.Errors[0].Detail)
+		return result, resp.StatusCode, apiErr
 
 	}
 
 	//all is well, return the response payload
-	return ioutil.ReadAll(resp.Body)
+	result, err = ioutil.ReadAll(resp.Body)
+	return result, resp.StatusCode, err
 
 }
@@ -0,0 +1,72 @@
+package buildingx
+
+import (
+	"context"
+	"time"
+)
+
+// CallEvent describes an HTTP call made to the Operations API or to the token endpoint
+type CallEvent struct {
+	// Operation is the logical operation the call belongs to (ex: GetLocations or GetToken)
+	Operation  string
+	Partition  string
+	Method     string
+	Path       string
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// Instrumentation receives every HTTP call made by a Client and every token refresh. It is the extension point
+// used to integrate tracing and metrics systems such as OpenTelemetry (see the buildingxotel module of this repository).
+// A Client without instrumentation does no additional work.
+type Instrumentation interface {
+	// StartCall is called before each call. It returns the context to make the call with and a function
+	// that is called with the completed event.
+	StartCall(ctx context.Context, event CallEvent) (context.Context, func(CallEvent))
+	// TokenRefreshed is called after every attempt to obtain a token for a session
+	TokenRefreshed(ctx context.Context, partition string, err error)
+}
+
+// partitionKey carries the partition of a token request, which is not otherwise known to the token exchange
+type partitionKey struct{}
+
+// WithInstrumentation sets the instrumentation that observes every call made by the client
+func WithInstrumentation(instrumentation Instrumentation) Option {
+	return func(c *Config) { c.Instrumentation = instrumentation }
+}
+
+// startCall notifies the instrumentation, if any, that a call is starting. The returned function must be
+// called with the outcome of the call.
+func (c *Client) startCall(ctx context.Context, event CallEvent) (context.Context, func(status int, err error)) {
+
+	if c.config.Instrumentation == nil {
+		return ctx, func(int, error) {}
+	}
+
+	if name, ok := ctx.Value(operationKey{}).(string); ok {
+		event.Operation = name
+	}
+	if event.Partition == "" {
+		event.Partition, _ = ctx.Value(partitionKey{}).(string)
+	}
+
+	start := time.Now()
+	ctx, done := c.config.Instrumentation.StartCall(ctx, event)
+	return ctx, func(status int, err error) {
+		event.StatusCode = status
+		event.Err = err
+		event.Duration = time.Since(start)
+		done(event)
+	}
+
+}
+
+// tokenRefreshed notifies the instrumentation, if any, of a token refresh
+func (c *Client) tokenRefreshed(ctx context.Context, partition string, err error) {
+
+	if c.config.Instrumentation != nil {
+		c.config.Instrumentation.TokenRefreshed(ctx, partition, err)
+	}
+
+}
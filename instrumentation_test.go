@@ -0,0 +1,59 @@
+package buildingx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingInstrumentation keeps every event it receives
+type recordingInstrumentation struct {
+	mu        sync.Mutex
+	events    []CallEvent
+	refreshes []string
+}
+
+func (r *recordingInstrumentation) StartCall(ctx context.Context, event CallEvent) (context.Context, func(CallEvent)) {
+	return ctx, func(event CallEvent) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.events = append(r.events, event)
+	}
+}
+func (r *recordingInstrumentation) TokenRefreshed(ctx context.Context, partition string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshes = append(r.refreshes, partition)
+}
+
+func TestInstrumentation(t *testing.T) {
+
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":"point-1"}}`)
+	}))
+	instrumentation := &recordingInstrumentation{}
+	client, err := NewClient(config, WithInstrumentation(instrumentation))
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+
+	_, err = client.GetSinglePoint("point-1")
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"partition"}, instrumentation.refreshes)
+	if len(instrumentation.events) != 2 {
+		t.Fatal("expected a token call and an API call but got: ", instrumentation.events)
+	}
+	token, call := instrumentation.events[0], instrumentation.events[1]
+	assert.Equal(t, "GetToken", token.Operation)
+	assert.Equal(t, "partition", token.Partition)
+	assert.Equal(t, "GetSinglePoint", call.Operation)
+	assert.Equal(t, "partition", call.Partition)
+	assert.Equal(t, 200, call.StatusCode)
+	assert.Equal(t, "points/point-1?field[Point]=pointValue", call.Path)
+
+}
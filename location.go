@@ -1,10 +1,10 @@
 package buildingx
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 )
 
 type Location struct {
@@ -64,43 +64,97 @@ type SBLocationIncludedAttributes struct {
 
 // GetLocations returns an array of all locations associated with the session. It also populates the session object with the locations.
 func GetLocations(session *Session) ([]Location, error) {
+	return GetLocationsWithContext(context.Background(), session)
+}
 
-	locations := make([]Location, 0)
+// GetLocationsWithContext is like GetLocations but uses the given context for the API call and any token refresh
+func GetLocationsWithContext(ctx context.Context, session *Session) ([]Location, error) {
+	return session.apiClient().getLocations(ctx, session)
+}
 
-	// make sure session is initialized
-	if !session.IsInitialized {
-		return locations, errors.New("session is not initialized")
+// GetLocations returns an array of all locations in the client partition
+func (c *Client) GetLocations() ([]Location, error) {
+	return c.GetLocationsWithContext(context.Background())
+}
+
+// GetLocationsWithContext is like GetLocations but uses the given context for the API call and any token refresh
+func (c *Client) GetLocationsWithContext(ctx context.Context) ([]Location, error) {
+	return c.getLocations(ctx, c.session)
+}
+
+// GetLocationsPages calls fn with each page of locations associated with the session. Iteration stops
+// when fn returns false or the last page has been read.
+func GetLocationsPages(session *Session, fn func(locations []Location, lastPage bool) bool) error {
+	return GetLocationsPagesWithContext(context.Background(), session, fn)
+}
+
+// GetLocationsPagesWithContext is like GetLocationsPages but uses the given context for the API calls and any token refresh
+func GetLocationsPagesWithContext(ctx context.Context, session *Session, fn func(locations []Location, lastPage bool) bool) error {
+	return session.apiClient().getLocationsPages(ctx, session, fn)
+}
+
+// GetLocationsPages calls fn with each page of locations in the client partition
+func (c *Client) GetLocationsPages(fn func(locations []Location, lastPage bool) bool) error {
+	return c.GetLocationsPagesWithContext(context.Background(), fn)
+}
+
+// GetLocationsPagesWithContext is like GetLocationsPages but uses the given context for the API calls and any token refresh
+func (c *Client) GetLocationsPagesWithContext(ctx context.Context, fn func(locations []Location, lastPage bool) bool) error {
+	return c.getLocationsPages(ctx, c.session, fn)
+}
+
+func (c *Client) getLocations(ctx context.Context, session *Session) ([]Location, error) {
+
+	locations := make([]Location, 0)
+	err := c.getLocationsPages(ctx, session, func(page []Location, lastPage bool) bool {
+		locations = append(locations, page...)
+		return true
+	})
+	if err != nil {
+		return make([]Location, 0), err
 	}
 
-	// make sure you have the required environment variable
-	endpoint := os.Getenv("BUILDINGX_ENDPOINT")
-	if endpoint == "" {
-		return locations, errors.New("missing buildingx api endpoint")
+	// all is well. return the locations
+	return locations, nil
+
+}
+func (c *Client) getLocationsPages(ctx context.Context, session *Session, fn func([]Location, bool) bool) error {
+
+	// make sure session is initialized
+	if !session.usable() {
+		return ErrSessionNotInitialized
 	}
 
 	// create the API request
 	req := APIRequest{
-		Partition: session.Partition,
-		JWT:       session.JWT,
+		Name:      "GetLocations",
 		Path:      "locations?filter[type]=Building&include=hasPostalAddress",
 		Operation: GET,
 	}
 
-	// make the API call
-	resp, err := MakeRESTCall(req)
-	if err != nil {
-		return locations, errors.New("error making REST call: " + err.Error())
-	}
+	// make the API calls, one per page
+	return c.pagedCall(ctx, session, req, func(payload []byte, lastPage bool) (bool, error) {
+		locations, err := parseLocationsJSON(payload)
+		if err != nil {
+			return false, err
+		}
+		return fn(locations, lastPage), nil
+	})
+
+}
+func parseLocationsJSON(payload []byte) ([]Location, error) {
+
+	locations := make([]Location, 0)
 
 	// Unmarshal the native location response payload
 	sbLocationsResponse := SBLocationsResponse{}
-	if err := json.Unmarshal(resp, &sbLocationsResponse); err != nil {
-		return locations, errors.New("Error parsing API response. String submitted: " + string(resp))
+	if err := json.Unmarshal(payload, &sbLocationsResponse); err != nil {
+		return locations, errors.New("Error parsing API response. String submitted: " + string(payload))
 	}
 
 	sbLocationsIncludedResponse := SBLocationIncludedResponse{}
-	if err := json.Unmarshal(resp, &sbLocationsIncludedResponse); err != nil {
-		return locations, errors.New("Error parsing API response. String submitted: " + string(resp))
+	if err := json.Unmarshal(payload, &sbLocationsIncludedResponse); err != nil {
+		return locations, errors.New("Error parsing API response. String submitted: " + string(payload))
 	}
 
 	// now create the Location objects
@@ -126,37 +180,48 @@ func GetLocations(session *Session) ([]Location, error) {
 
 	}
 
-	// all is well. return the locations
 	return locations, nil
 
 }
 func GetSingleLocation(session *Session, id string) (Location, error) {
+	return GetSingleLocationWithContext(context.Background(), session, id)
+}
+
+// GetSingleLocationWithContext is like GetSingleLocation but uses the given context for the API call and any token refresh
+func GetSingleLocationWithContext(ctx context.Context, session *Session, id string) (Location, error) {
+	return session.apiClient().getSingleLocation(ctx, session, id)
+}
+
+// GetSingleLocation returns a single location by its id
+func (c *Client) GetSingleLocation(id string) (Location, error) {
+	return c.GetSingleLocationWithContext(context.Background(), id)
+}
+
+// GetSingleLocationWithContext is like GetSingleLocation but uses the given context for the API call and any token refresh
+func (c *Client) GetSingleLocationWithContext(ctx context.Context, id string) (Location, error) {
+	return c.getSingleLocation(ctx, c.session, id)
+}
+
+func (c *Client) getSingleLocation(ctx context.Context, session *Session, id string) (Location, error) {
 
 	location := Location{}
 	// make sure session is initialized
-	if !session.IsInitialized {
-		return location, errors.New("session is not initialized")
-	}
-
-	// make sure you have the required environment variable
-	endpoint := os.Getenv("BUILDINGX_ENDPOINT")
-	if endpoint == "" {
-		return location, errors.New("missing buildingx api endpoint")
+	if !session.usable() {
+		return location, ErrSessionNotInitialized
 	}
 
 	// create the API request
 	path := fmt.Sprintf("locations/%s?include=hasPostalAddress", id)
 	req := APIRequest{
-		Partition: session.Partition,
-		JWT:       session.JWT,
+		Name:      "GetSingleLocation",
 		Path:      path,
 		Operation: GET,
 	}
 
 	// make the API call
-	resp, err := MakeRESTCall(req)
+	resp, err := c.call(ctx, session, req)
 	if err != nil {
-		return location, errors.New("error making REST call: " + err.Error())
+		return location, fmt.Errorf("error making REST call: %w", err)
 	}
 
 	// Unmarshal the native location response payload
@@ -2,7 +2,6 @@ package buildingx
 
 import (
 	"context"
-	"os"
 	"testing"
 
 	"github.com/aws/aws-xray-sdk-go/xray"
@@ -13,11 +12,8 @@ func TestGetLocations(t *testing.T) {
 	ctx := context.Background()
 	ctx, _ = xray.BeginSegment(ctx, "TestGetLocations")
 
-	// first make sure you have a partition ID (from environment variable)
-	partitionID := os.Getenv("BUILDINGX_PARTITION_ID")
-	if partitionID == "" {
-		t.Fatal("unable to find partition ID in environment variable")
-	}
+	// first make sure you have a partition ID and credentials (the test is skipped without them)
+	partitionID := integrationPartition(t)
 
 	// initialize the session (uses credentials to authenticate and produce a JWT)
 	session := Session{}
@@ -50,11 +46,8 @@ func TestGetSingleLocation(t *testing.T) {
 	ctx := context.Background()
 	ctx, _ = xray.BeginSegment(ctx, "TestGetSingleLocations")
 
-	// first make sure you have a partition ID (from environment variable)
-	partitionID := os.Getenv("BUILDINGX_PARTITION_ID")
-	if partitionID == "" {
-		t.Fatal("unable to find partition ID in environment variable")
-	}
+	// first make sure you have a partition ID and credentials (the test is skipped without them)
+	partitionID := integrationPartition(t)
 
 	// initialize the session (uses credentials to authenticate and produce a JWT)
 	session := Session{}
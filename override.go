@@ -0,0 +1,660 @@
+package buildingx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultRevertRetryDelay = time.Minute
+
+var (
+	// ErrOverrideNotFound is returned when reverting an override that is not pending
+	ErrOverrideNotFound = errors.New("override not found")
+	// ErrSchedulerClosed is returned when an override is made on a closed OverrideScheduler
+	ErrSchedulerClosed = errors.New("override scheduler is closed")
+)
+
+// Override is a temporary command on a point that is reverted when it expires
+type Override struct {
+	ID        string `json:"id"`
+	Partition string `json:"partition"`
+	PointID   string `json:"pointId"`
+	DataType  string `json:"dataType"`
+	// Value is the commanded value
+	Value string `json:"value"`
+	// PriorValue is the value the point had before the override, which is restored when Release is false
+	PriorValue string `json:"priorValue"`
+	// Priority is the BACnet priority of the override. When the override is made without the Priority option,
+	// it is the slot the API chose, found by comparing the priority array of the point before and after the
+	// command. It is zero when the point does not report a priority array.
+	Priority int `json:"priority,omitempty"`
+	// Release reverts the override by releasing Priority instead of commanding PriorValue. It is set when the
+	// priority slot was empty before the override.
+	Release bool      `json:"release,omitempty"`
+	Start   time.Time `json:"start"`
+	Expires time.Time `json:"expires"`
+}
+
+// OverrideStore persists the pending overrides of an OverrideScheduler, so that they can be reverted after a
+// restart. Implementations must be safe for use by many goroutines.
+type OverrideStore interface {
+	SaveOverride(ctx context.Context, override Override) error
+	DeleteOverride(ctx context.Context, id string) error
+	ListOverrides(ctx context.Context) ([]Override, error)
+}
+
+// OverrideOption changes the behavior of an OverrideScheduler
+type OverrideOption func(*OverrideScheduler)
+
+// OnRevert sets a function that is called after every attempt to revert an override. err is nil when the
+// override was reverted.
+func OnRevert(fn func(override Override, err error)) OverrideOption {
+	return func(s *OverrideScheduler) { s.onRevert = fn }
+}
+
+// RevertRetryDelay sets how long the scheduler waits before attempting again to revert an override that
+// could not be reverted. It defaults to a minute.
+func RevertRetryDelay(delay time.Duration) OverrideOption {
+	return func(s *OverrideScheduler) { s.retryDelay = delay }
+}
+
+// OverrideScheduler commands points for a limited time and reverts them when the time is up. Pending
+// overrides are kept in an OverrideStore; call Resume when the process starts to revert or reschedule the
+// overrides left by a previous run.
+type OverrideScheduler struct {
+	session    *Session
+	store      OverrideStore
+	onRevert   func(Override, error)
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingOverride
+	closed  bool
+}
+
+// pendingOverride is an override waiting for its timer
+type pendingOverride struct {
+	override Override
+	timer    *time.Timer
+}
+
+// NewOverrideScheduler returns a scheduler that makes overrides through the session. The overrides are kept in
+// memory when store is nil.
+func NewOverrideScheduler(session *Session, store OverrideStore, opts ...OverrideOption) *OverrideScheduler {
+
+	if store == nil {
+		store = NewMemoryOverrideStore()
+	}
+	s := &OverrideScheduler{
+		session:    session,
+		store:      store,
+		retryDelay: defaultRevertRetryDelay,
+		pending:    make(map[string]*pendingOverride),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+
+}
+
+// NewOverrideScheduler returns a scheduler that makes overrides through the client session
+func (c *Client) NewOverrideScheduler(store OverrideStore, opts ...OverrideOption) *OverrideScheduler {
+	return NewOverrideScheduler(c.session, store, opts...)
+}
+
+// Override commands the point to value for the given duration and then reverts it. The current value of the
+// point is read first so that it can be restored. When the priority slot was empty, the override is reverted by
+// releasing the priority instead, so that a schedule or the relinquish default controls the point again.
+// Without the Priority option, the slot is the one the API chose for the command. Overriding a point that
+// already has a pending override at the same priority replaces it, and the value from before the first override
+// is the one restored. When the command is made but the store cannot be updated, the override is still
+// scheduled and it is returned along with the error.
+func (s *OverrideScheduler) Override(point *Point, value string, duration time.Duration, opts ...CommandOption) (Override, error) {
+	return s.OverrideWithContext(context.Background(), point, value, duration, opts...)
+}
+
+// OverrideWithContext is like Override but uses the given context for the API calls and the store
+func (s *OverrideScheduler) OverrideWithContext(ctx context.Context, point *Point, value string, duration time.Duration, opts ...CommandOption) (Override, error) {
+
+	if s.isClosed() {
+		return Override{}, ErrSchedulerClosed
+	}
+
+	client := s.session.apiClient()
+	options := newCommandOptions(opts)
+	if err := options.check(point, value); err != nil {
+		return Override{}, err
+	}
+
+	prior, err := client.priorState(ctx, s.session, point.ID, options.priority)
+	if err != nil {
+		return Override{}, err
+	}
+
+	now := time.Now()
+	override := Override{
+		ID:         newOverrideID(),
+		Partition:  s.partition(),
+		PointID:    point.ID,
+		DataType:   point.DataType,
+		Value:      value,
+		PriorValue: prior.value,
+		Priority:   prior.priority,
+		Release:    prior.release,
+		Start:      now,
+		Expires:    now.Add(duration),
+	}
+
+	// an override of a point that is already overridden keeps the original value to restore
+	replaced := s.replace(override)
+	if replaced != nil {
+		override.PriorValue, override.Release = replaced.PriorValue, replaced.Release
+	}
+
+	// the override is saved before the command, so that a crash in between still reverts it
+	if err := s.store.SaveOverride(ctx, override); err != nil {
+		s.restore(replaced)
+		return Override{}, fmt.Errorf("error saving override: %w", err)
+	}
+	if err := client.commandPointValue(ctx, s.session, point, value, opts...); err != nil {
+		s.restore(replaced)
+		if deleteErr := s.store.DeleteOverride(ctx, override.ID); deleteErr != nil {
+			return Override{}, fmt.Errorf("%w (and error deleting override: %v)", err, deleteErr)
+		}
+		return Override{}, err
+	}
+
+	// the command is made, so the override is scheduled even when the store cannot be updated
+	var storeErr error
+
+	// without a priority, the slot of the override is only known once the command is made
+	if options.priority == 0 {
+		if client.findCommandPriority(ctx, s.session, point.ID, &prior) {
+			override.PriorValue, override.Priority, override.Release = prior.value, prior.priority, prior.release
+			if replaced == nil {
+				replaced = s.replace(override)
+			}
+		} else if replaced == nil {
+			// no slot changed, so the command went to a slot that already held the value (ex: an override
+			// extended with the same value), and the pending override of the point is the one replaced
+			replaced = s.replaceOnPoint(override)
+			if replaced != nil {
+				override.Priority = replaced.Priority
+			}
+		}
+		if replaced != nil {
+			override.PriorValue, override.Release = replaced.PriorValue, replaced.Release
+		}
+		if err := s.store.SaveOverride(ctx, override); err != nil {
+			storeErr = fmt.Errorf("error saving override: %w", err)
+		}
+	}
+	if replaced != nil {
+		if err := s.store.DeleteOverride(ctx, replaced.ID); err != nil && storeErr == nil {
+			storeErr = fmt.Errorf("error deleting replaced override: %w", err)
+		}
+	}
+
+	s.schedule(override, duration)
+	return override, storeErr
+
+}
+
+// Revert reverts a pending override now instead of waiting for it to expire
+func (s *OverrideScheduler) Revert(id string) error {
+	return s.RevertWithContext(context.Background(), id)
+}
+
+// RevertWithContext is like Revert but uses the given context for the API call and the store
+func (s *OverrideScheduler) RevertWithContext(ctx context.Context, id string) error {
+
+	s.mu.Lock()
+	entry, ok := s.pending[id]
+	if ok {
+		entry.timer.Stop()
+		delete(s.pending, id)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrOverrideNotFound
+	}
+	return s.revert(ctx, entry.override)
+
+}
+
+// Resume loads the overrides of the session partition from the store. Expired overrides are reverted right
+// away and the others are scheduled.
+func (s *OverrideScheduler) Resume() error {
+	return s.ResumeWithContext(context.Background())
+}
+
+// ResumeWithContext is like Resume but uses the given context for the store and the API calls
+func (s *OverrideScheduler) ResumeWithContext(ctx context.Context) error {
+
+	overrides, err := s.store.ListOverrides(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing overrides: %w", err)
+	}
+
+	partition := s.partition()
+	var errs []error
+	for _, override := range overrides {
+		if override.Partition != partition || s.isPending(override.ID) {
+			continue
+		}
+		wait := time.Until(override.Expires)
+		if wait > 0 {
+			s.schedule(override, wait)
+			continue
+		}
+		if err := s.revert(ctx, override); err != nil {
+			errs = append(errs, err)
+			s.schedule(override, s.retryDelay)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("error reverting %d expired override(s): %w", len(errs), errs[0])
+	}
+	return nil
+
+}
+
+// Pending returns the overrides waiting to be reverted, in order of expiration
+func (s *OverrideScheduler) Pending() []Override {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	overrides := make([]Override, 0, len(s.pending))
+	for _, entry := range s.pending {
+		overrides = append(overrides, entry.override)
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Expires.Before(overrides[j].Expires) })
+	return overrides
+
+}
+
+// Close stops the timers of the pending overrides without reverting them. They stay in the store, so a
+// scheduler using the same store can resume them.
+func (s *OverrideScheduler) Close() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for id, entry := range s.pending {
+		entry.timer.Stop()
+		delete(s.pending, id)
+	}
+
+}
+
+// schedule reverts the override after wait
+func (s *OverrideScheduler) schedule(override Override, wait time.Duration) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	entry := &pendingOverride{override: override}
+	entry.timer = time.AfterFunc(wait, func() { s.expire(entry) })
+	s.pending[override.ID] = entry
+
+}
+
+// expire reverts an override whose timer fired, and tries again later when it fails
+func (s *OverrideScheduler) expire(entry *pendingOverride) {
+
+	s.mu.Lock()
+	current := s.pending[entry.override.ID]
+	if current == entry {
+		delete(s.pending, entry.override.ID)
+	}
+	s.mu.Unlock()
+
+	// the override was reverted, replaced or the scheduler was closed
+	if current != entry {
+		return
+	}
+
+	if err := s.revert(context.Background(), entry.override); err != nil {
+		s.schedule(entry.override, s.retryDelay)
+	}
+
+}
+
+// revert restores the point and removes the override from the store
+func (s *OverrideScheduler) revert(ctx context.Context, override Override) error {
+
+	point := &Point{ID: override.PointID, DataType: override.DataType, Writable: true}
+	err := s.session.apiClient().restoreCommand(ctx, s.session, point, override.Priority, override.PriorValue, override.Release)
+	if err == nil {
+		if deleteErr := s.store.DeleteOverride(ctx, override.ID); deleteErr != nil {
+			err = fmt.Errorf("error deleting override: %w", deleteErr)
+		}
+	}
+
+	if s.onRevert != nil {
+		s.onRevert(override, err)
+	}
+	return err
+
+}
+
+// priorCommand is the state a point is restored to after a command
+type priorCommand struct {
+	priority int
+	value    string
+	release  bool
+	// slots is the priority array before the command
+	slots []PriorityValue
+}
+
+// priorState reads the value a point must be restored to after it is commanded at priority. When a priority
+// is given and its slot is empty, the command is undone by releasing the priority instead. Without a priority,
+// findCommandPriority completes the state once the command is made.
+func (c *Client) priorState(ctx context.Context, session *Session, pointID string, priority int) (priorCommand, error) {
+
+	current, err := c.getSinglePoint(ctx, session, pointID)
+	if err != nil {
+		return priorCommand{}, fmt.Errorf("error reading prior value: %w", err)
+	}
+	prior := priorCommand{priority: priority, value: current.StringValue, slots: current.PriorityArray}
+	if priority == 0 {
+		return prior, nil
+	}
+	for _, slot := range current.PriorityArray {
+		if slot.Priority == priority {
+			prior.value = slot.Value
+			return prior, nil
+		}
+	}
+	prior.release = true
+	return prior, nil
+
+}
+
+// findCommandPriority finds the slot taken by a command made without a priority, by comparing the priority
+// array of the point with the one before the command, and sets the prior state of that slot. It reports
+// false when no slot changed (ex: the point does not report a priority array or could not be read), in which
+// case the command is undone by commanding the prior value at the priority the API chooses.
+func (c *Client) findCommandPriority(ctx context.Context, session *Session, pointID string, prior *priorCommand) bool {
+
+	current, err := c.getSinglePoint(ctx, session, pointID)
+	if err != nil {
+		return false
+	}
+	before := make(map[int]string, len(prior.slots))
+	for _, slot := range prior.slots {
+		before[slot.Priority] = slot.Value
+	}
+	for _, slot := range current.PriorityArray {
+		value, occupied := before[slot.Priority]
+		if occupied && value == slot.Value {
+			continue
+		}
+		prior.priority, prior.release = slot.Priority, !occupied
+		if occupied {
+			prior.value = value
+		}
+		return true
+	}
+	return false
+
+}
+
+// restoreCommand undoes a command made at priority by releasing the priority or by commanding the prior value
+func (c *Client) restoreCommand(ctx context.Context, session *Session, point *Point, priority int, prior string, release bool) error {
+
+	if release {
+		return c.releasePointCommand(ctx, session, point, Priority(priority))
+	}
+	return c.commandPointValue(ctx, session, point, prior, Force(), Priority(priority))
+
+}
+
+// replace stops and removes a pending override of the same point and priority as override, and returns it
+func (s *OverrideScheduler) replace(override Override) *Override {
+	return s.take(func(pending Override) bool {
+		return pending.PointID == override.PointID && pending.Priority == override.Priority
+	})
+}
+
+// replaceOnPoint is like replace for an override whose priority is unknown. It takes a pending override of the
+// same point, preferably one holding the same value.
+func (s *OverrideScheduler) replaceOnPoint(override Override) *Override {
+
+	if replaced := s.take(func(pending Override) bool {
+		return pending.PointID == override.PointID && pending.Value == override.Value
+	}); replaced != nil {
+		return replaced
+	}
+	return s.take(func(pending Override) bool { return pending.PointID == override.PointID })
+
+}
+
+// take stops and removes the first pending override that matches, and returns it
+func (s *OverrideScheduler) take(match func(Override) bool) *Override {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, entry := range s.pending {
+		if match(entry.override) {
+			entry.timer.Stop()
+			delete(s.pending, id)
+			replaced := entry.override
+			return &replaced
+		}
+	}
+	return nil
+
+}
+
+// restore schedules again an override taken out by replace when the new override failed
+func (s *OverrideScheduler) restore(override *Override) {
+
+	if override != nil {
+		s.schedule(*override, time.Until(override.Expires))
+	}
+
+}
+
+func (s *OverrideScheduler) isPending(id string) bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.pending[id]
+	return ok
+
+}
+
+func (s *OverrideScheduler) isClosed() bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+
+}
+
+func (s *OverrideScheduler) partition() string {
+	return s.session.PartitionID()
+}
+
+func newOverrideID() string {
+
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+
+}
+
+// MemoryOverrideStore keeps overrides in memory. Pending overrides are lost when the process exits.
+type MemoryOverrideStore struct {
+	mu        sync.Mutex
+	overrides map[string]Override
+}
+
+// NewMemoryOverrideStore returns an empty in-memory store
+func NewMemoryOverrideStore() *MemoryOverrideStore {
+	return &MemoryOverrideStore{overrides: make(map[string]Override)}
+}
+
+// SaveOverride adds or replaces the override
+func (m *MemoryOverrideStore) SaveOverride(ctx context.Context, override Override) error {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.overrides[override.ID] = override
+	return nil
+
+}
+
+// DeleteOverride removes the override. Removing an unknown override is not an error.
+func (m *MemoryOverrideStore) DeleteOverride(ctx context.Context, id string) error {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.overrides, id)
+	return nil
+
+}
+
+// ListOverrides returns every override in the store
+func (m *MemoryOverrideStore) ListOverrides(ctx context.Context) ([]Override, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	overrides := make([]Override, 0, len(m.overrides))
+	for _, override := range m.overrides {
+		overrides = append(overrides, override)
+	}
+	return overrides, nil
+
+}
+
+// FileOverrideStore keeps overrides in a JSON file, so that they survive a restart. The file is replaced
+// atomically on every change.
+type FileOverrideStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileOverrideStore returns a store backed by the file at path, which is created on the first save
+func NewFileOverrideStore(path string) *FileOverrideStore {
+	return &FileOverrideStore{path: path}
+}
+
+// SaveOverride adds or replaces the override
+func (f *FileOverrideStore) SaveOverride(ctx context.Context, override Override) error {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	overrides, err := f.read()
+	if err != nil {
+		return err
+	}
+	for i := range overrides {
+		if overrides[i].ID == override.ID {
+			overrides[i] = override
+			return f.write(overrides)
+		}
+	}
+	return f.write(append(overrides, override))
+
+}
+
+// DeleteOverride removes the override. Removing an unknown override is not an error.
+func (f *FileOverrideStore) DeleteOverride(ctx context.Context, id string) error {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	overrides, err := f.read()
+	if err != nil {
+		return err
+	}
+	kept := overrides[:0]
+	for _, override := range overrides {
+		if override.ID != id {
+			kept = append(kept, override)
+		}
+	}
+	return f.write(kept)
+
+}
+
+// ListOverrides returns every override in the file
+func (f *FileOverrideStore) ListOverrides(ctx context.Context) ([]Override, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.read()
+
+}
+
+func (f *FileOverrideStore) read() ([]Override, error) {
+
+	data, err := ioutil.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make([]Override, 0), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading overrides: %w", err)
+	}
+
+	overrides := make([]Override, 0)
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("error unmarshalling overrides: %w", err)
+	}
+	return overrides, nil
+
+}
+
+func (f *FileOverrideStore) write(overrides []Override) error {
+
+	data, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling overrides: %w", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("error writing overrides: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing overrides: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing overrides: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("error writing overrides: %w", err)
+	}
+	return nil
+
+}
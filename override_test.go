@@ -0,0 +1,223 @@
+package buildingx_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+	"github.com/cloudlinesolutions/buildingx-operations-api/buildingxtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// commandsOf returns the commands received by the fake, without their time
+func commandsOf(server *buildingxtest.Server) []buildingxtest.Command {
+
+	commands := server.Commands()
+	for i := range commands {
+		commands[i].Time = time.Time{}
+	}
+	return commands
+
+}
+
+// failingStore is a memory store whose saves fail after the first saveAfter ones and whose deletes always fail
+type failingStore struct {
+	buildingx.OverrideStore
+	saveAfter int
+	saves     int
+}
+
+func (s *failingStore) SaveOverride(ctx context.Context, override buildingx.Override) error {
+
+	s.saves++
+	if s.saves > s.saveAfter {
+		return errors.New("disk full")
+	}
+	return s.OverrideStore.SaveOverride(ctx, override)
+
+}
+
+func (s *failingStore) DeleteOverride(ctx context.Context, id string) error {
+	return errors.New("disk full")
+}
+
+func TestOverrideScheduler(t *testing.T) {
+
+	t.Run("reverted-when-expired", func(t *testing.T) {
+		server, client := newSite(t)
+		point := sitePoint(t, client, "point-3")
+		reverted := make(chan error, 1)
+		scheduler := client.NewOverrideScheduler(nil, buildingx.OnRevert(func(override buildingx.Override, err error) { reverted <- err }))
+		defer scheduler.Close()
+
+		override, err := scheduler.Override(point, "68", 20*time.Millisecond)
+		if err != nil {
+			t.Fatal("error overriding point: ", err.Error())
+		}
+		assert.Equal(t, "72", override.PriorValue)
+		assert.Equal(t, buildingx.PriorityDefault, override.Priority)
+		assert.True(t, override.Release)
+		assert.Equal(t, 1, len(scheduler.Pending()))
+		assert.Equal(t, "68", sitePoint(t, client, "point-3").StringValue)
+
+		// the command is released rather than overwritten, so the point is back at its relinquish default
+		assert.Nil(t, <-reverted)
+		observed := sitePoint(t, client, "point-3")
+		assert.Equal(t, "72", observed.StringValue)
+		assert.Nil(t, observed.PriorityArray)
+		assert.Equal(t, 0, len(scheduler.Pending()))
+		assert.Equal(t, []buildingxtest.Command{
+			{PointID: "point-3", Value: "68", Priority: 16},
+			{PointID: "point-3", Priority: 16, Release: true},
+		}, commandsOf(server))
+	})
+	t.Run("empty-priority-is-released", func(t *testing.T) {
+		server, client := newSite(t)
+		point := sitePoint(t, client, "point-3")
+		scheduler := client.NewOverrideScheduler(nil)
+		defer scheduler.Close()
+
+		override, err := scheduler.Override(point, "68", time.Hour, buildingx.Priority(buildingx.PriorityManualOperator))
+		if err != nil {
+			t.Fatal("error overriding point: ", err.Error())
+		}
+		assert.True(t, override.Release)
+		assert.Nil(t, scheduler.Revert(override.ID))
+		assert.Equal(t, []buildingxtest.Command{
+			{PointID: "point-3", Value: "68", Priority: 8},
+			{PointID: "point-3", Priority: 8, Release: true},
+		}, commandsOf(server))
+		assert.True(t, errors.Is(scheduler.Revert(override.ID), buildingx.ErrOverrideNotFound))
+	})
+	t.Run("occupied-slot-is-restored", func(t *testing.T) {
+		_, client := newSite(t)
+		point := sitePoint(t, client, "point-3")
+		if err := client.CommandPointValue(point, "70"); err != nil {
+			t.Fatal("error commanding point: ", err.Error())
+		}
+		scheduler := client.NewOverrideScheduler(nil)
+		defer scheduler.Close()
+
+		override, err := scheduler.Override(point, "68", time.Hour)
+		if err != nil {
+			t.Fatal("error overriding point: ", err.Error())
+		}
+		assert.Equal(t, buildingx.PriorityDefault, override.Priority)
+		assert.Equal(t, "70", override.PriorValue)
+		assert.False(t, override.Release)
+		assert.Nil(t, scheduler.Revert(override.ID))
+		assert.Equal(t, []buildingx.PriorityValue{{Priority: 16, Value: "70"}}, sitePoint(t, client, "point-3").PriorityArray)
+	})
+	t.Run("replacing-keeps-the-original-value", func(t *testing.T) {
+		_, client := newSite(t)
+		point := sitePoint(t, client, "point-3")
+		scheduler := client.NewOverrideScheduler(nil)
+		defer scheduler.Close()
+
+		_, err := scheduler.Override(point, "68", time.Hour)
+		if err != nil {
+			t.Fatal("error overriding point: ", err.Error())
+		}
+		override, err := scheduler.Override(point, "66", time.Hour)
+		if err != nil {
+			t.Fatal("error overriding point: ", err.Error())
+		}
+		assert.Equal(t, "72", override.PriorValue)
+		assert.True(t, override.Release)
+		assert.Equal(t, []buildingx.Override{override}, scheduler.Pending())
+		assert.Nil(t, scheduler.Revert(override.ID))
+		observed := sitePoint(t, client, "point-3")
+		assert.Equal(t, "72", observed.StringValue)
+		assert.Nil(t, observed.PriorityArray)
+	})
+	t.Run("extending-with-the-same-value-is-released", func(t *testing.T) {
+		server, client := newSite(t)
+		point := sitePoint(t, client, "point-3")
+		reverted := make(chan error, 2)
+		scheduler := client.NewOverrideScheduler(nil, buildingx.OnRevert(func(override buildingx.Override, err error) { reverted <- err }))
+		defer scheduler.Close()
+
+		_, err := scheduler.Override(point, "68", 20*time.Millisecond)
+		if err != nil {
+			t.Fatal("error overriding point: ", err.Error())
+		}
+		extended, err := scheduler.Override(point, "68", 40*time.Millisecond)
+		if err != nil {
+			t.Fatal("error overriding point: ", err.Error())
+		}
+		assert.Equal(t, buildingx.PriorityDefault, extended.Priority)
+		assert.Equal(t, "72", extended.PriorValue)
+		assert.True(t, extended.Release)
+		assert.Equal(t, []buildingx.Override{extended}, scheduler.Pending())
+
+		assert.Nil(t, <-reverted)
+		observed := sitePoint(t, client, "point-3")
+		assert.Equal(t, "72", observed.StringValue)
+		assert.Nil(t, observed.PriorityArray)
+		assert.Equal(t, []buildingxtest.Command{
+			{PointID: "point-3", Value: "68", Priority: 16},
+			{PointID: "point-3", Value: "68", Priority: 16},
+			{PointID: "point-3", Priority: 16, Release: true},
+		}, commandsOf(server))
+		assert.Equal(t, 0, len(reverted))
+	})
+	t.Run("store-errors-are-returned", func(t *testing.T) {
+		server, client := newSite(t)
+		point := sitePoint(t, client, "point-3")
+		scheduler := client.NewOverrideScheduler(&failingStore{OverrideStore: buildingx.NewMemoryOverrideStore(), saveAfter: 1})
+		defer scheduler.Close()
+
+		// the slot is saved once the command is made, and the override is scheduled even though that fails
+		override, err := scheduler.Override(point, "68", time.Hour)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "error saving override")
+		assert.Equal(t, []buildingx.Override{override}, scheduler.Pending())
+
+		// a failed command is reported along with the override that could not be deleted
+		server.InjectFault(buildingxtest.Fault{Method: "PATCH", Path: "points/point-4", Status: 500})
+		scheduler = client.NewOverrideScheduler(&failingStore{OverrideStore: buildingx.NewMemoryOverrideStore(), saveAfter: 1})
+		defer scheduler.Close()
+		_, err = scheduler.Override(sitePoint(t, client, "point-4"), "1", time.Hour, buildingx.Priority(8))
+		var apiErr *buildingx.APIError
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Contains(t, err.Error(), "error deleting override")
+	})
+	t.Run("resumed-after-restart", func(t *testing.T) {
+		_, client := newSite(t)
+		point := sitePoint(t, client, "point-3")
+		store := buildingx.NewFileOverrideStore(filepath.Join(t.TempDir(), "overrides.json"))
+
+		scheduler := client.NewOverrideScheduler(store)
+		expired, err := scheduler.Override(point, "68", time.Hour)
+		if err != nil {
+			t.Fatal("error overriding point: ", err.Error())
+		}
+		long, err := scheduler.Override(point, "70", time.Hour, buildingx.Priority(8))
+		if err != nil {
+			t.Fatal("error overriding point: ", err.Error())
+		}
+		scheduler.Close()
+		_, err = scheduler.Override(point, "68", time.Hour)
+		assert.True(t, errors.Is(err, buildingx.ErrSchedulerClosed))
+
+		// the first override expires while the process is down
+		saved, _ := store.ListOverrides(context.Background())
+		assert.Equal(t, 2, len(saved))
+		expired.Expires = time.Now().Add(-time.Minute)
+		assert.Nil(t, store.SaveOverride(context.Background(), expired))
+
+		restarted := client.NewOverrideScheduler(store)
+		defer restarted.Close()
+		assert.Nil(t, restarted.Resume())
+		assert.Equal(t, []string{long.ID}, []string{restarted.Pending()[0].ID})
+		saved, _ = store.ListOverrides(context.Background())
+		assert.Equal(t, 1, len(saved))
+		observed := sitePoint(t, client, "point-3")
+		assert.Equal(t, "70", observed.StringValue)
+		assert.Equal(t, []buildingx.PriorityValue{{Priority: 8, Value: "70"}}, observed.PriorityArray)
+	})
+
+}
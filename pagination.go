@@ -0,0 +1,114 @@
+package buildingx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// maxPages protects against a pagination link that never ends
+const maxPages = 10000
+
+type SBPageResponse struct {
+	Links SBPageLinks `json:"links"`
+	Meta  SBPageMeta  `json:"meta"`
+}
+type SBPageLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next"`
+}
+type SBPageMeta struct {
+	TotalCount int `json:"totalCount"`
+}
+
+// pagedCall makes the API request and follows the JSON:API links.next of the response until the last
+// page has been read. Each page payload is handed to fn, which returns false to stop early.
+func (c *Client) pagedCall(ctx context.Context, session *Session, apiReq APIRequest, fn func(payload []byte, lastPage bool) (bool, error)) error {
+
+	return c.operation(ctx, apiReq.Name, session, func(ctx context.Context) error {
+		return c.followPages(ctx, session, apiReq, fn)
+	})
+
+}
+
+func (c *Client) followPages(ctx context.Context, session *Session, apiReq APIRequest, fn func(payload []byte, lastPage bool) (bool, error)) error {
+
+	visited := make(map[string]bool)
+
+	for page := 0; page < maxPages; page++ {
+
+		visited[apiReq.Path] = true
+
+		// make the API call
+		resp, err := c.call(ctx, session, apiReq)
+		if err != nil {
+			return fmt.Errorf("error making REST call: %w", err)
+		}
+
+		// find the link to the next page, if any
+		sbPageResponse := SBPageResponse{}
+		if err := json.Unmarshal(resp, &sbPageResponse); err != nil {
+			return errors.New("Error parsing API response. String submitted: " + string(resp))
+		}
+		next := ""
+		if sbPageResponse.Links.Next != "" {
+			next, err = nextPagePath(sbPageResponse.Links.Next)
+			if err != nil {
+				return err
+			}
+			// a link back to a page that was already read would loop forever
+			if visited[next] {
+				next = ""
+			}
+		}
+
+		more, err := fn(resp, next == "")
+		if err != nil {
+			return err
+		}
+		if !more || next == "" {
+			return nil
+		}
+
+		apiReq.Path = next
+
+	}
+
+	return fmt.Errorf("stopped following pagination links after %d pages", maxPages)
+
+}
+
+// nextPagePath converts a links.next value into a path relative to the partition, which is the form
+// expected by APIRequest. The link may be absolute, relative to the host or already relative to the partition.
+func nextPagePath(link string) (string, error) {
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse pagination link %s: %s", link, err.Error())
+	}
+	if !u.IsAbs() && !strings.HasPrefix(link, "/") {
+		return link, nil
+	}
+
+	// strip everything up to and including /partitions/{partition}/
+	const marker = "/partitions/"
+	idx := strings.Index(u.EscapedPath(), marker)
+	if idx < 0 {
+		return "", fmt.Errorf("unable to follow pagination link %s", link)
+	}
+	rest := u.EscapedPath()[idx+len(marker):]
+	slash := strings.Index(rest, "/")
+	if slash < 0 {
+		return "", fmt.Errorf("unable to follow pagination link %s", link)
+	}
+
+	path := rest[slash+1:]
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path, nil
+
+}
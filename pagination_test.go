@@ -0,0 +1,62 @@
+package buildingx
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPagination(t *testing.T) {
+
+	// three pages of one device each. The first page links with an absolute URL, the second with a relative one.
+	var requests int
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Query().Get("page[after]") {
+		case "":
+			fmt.Fprintf(w, `{"data":[{"id":"dev-1"}],"links":{"next":"http://%s/operations/partitions/partition/devices?page[after]=1"}}`, r.Host)
+		case "1":
+			fmt.Fprint(w, `{"data":[{"id":"dev-2"}],"links":{"next":"devices?page[after]=2"}}`)
+		default:
+			fmt.Fprint(w, `{"data":[{"id":"dev-3"}],"links":{}}`)
+		}
+	}))
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+
+	t.Run("all-pages-are-followed", func(t *testing.T) {
+		requests = 0
+		devices, err := client.GetAllDevices()
+		if err != nil {
+			t.Fatal("error getting devices: ", err.Error())
+		}
+		assert.Equal(t, 3, len(devices))
+		assert.Equal(t, "dev-3", devices[2].ID)
+		assert.Equal(t, 3, requests)
+	})
+	t.Run("iteration-stops-early", func(t *testing.T) {
+		requests = 0
+		pages := 0
+		err := client.GetAllDevicesPages(func(devices []Device, lastPage bool) bool {
+			pages++
+			assert.False(t, lastPage)
+			return false
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, pages)
+		assert.Equal(t, 1, requests)
+	})
+	t.Run("next-page-path", func(t *testing.T) {
+		path, err := nextPagePath("https://api.example.com/operations/partitions/p-1/points/abc/values?page[after]=x")
+		assert.Nil(t, err)
+		assert.Equal(t, "points/abc/values?page[after]=x", path)
+
+		_, err = nextPagePath("https://api.example.com/somewhere/else")
+		assert.NotNil(t, err)
+	})
+
+}
@@ -2,10 +2,12 @@ package buildingx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,6 +20,32 @@ type Point struct {
 	Status      string    `json:"status"`
 	StringValue string    `json:"stringValue"`
 	Timestamp   time.Time `json:"timestamp"`
+
+	// Units are the engineering units of a numeric point (ex: °F)
+	Units string `json:"units,omitempty"`
+	// Min and Max are the limits of the present value, when the point has them
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	// Resolution is the smallest change of value the point reports, when known
+	Resolution *float64 `json:"resolution,omitempty"`
+	// StateTexts are the labels of the states of an enumerated point, starting with state 1. For a boolean
+	// point they are the inactive and active texts.
+	StateTexts []string `json:"stateTexts,omitempty"`
+	// BACnetReference is the BACnet object reference of the point (ex: 2098177/analogValue:3)
+	BACnetReference string `json:"bacnetReference,omitempty"`
+	// Priority is the BACnet priority (1 to 16) of the command that controls the present value. It is zero
+	// when the point is not commanded or the API does not report priorities.
+	Priority int `json:"priority,omitempty"`
+	// PriorityArray lists the occupied slots of the BACnet priority array, highest priority first
+	PriorityArray []PriorityValue `json:"priorityArray,omitempty"`
+	// Attributes holds every attribute of the API payload, including those not modeled by Point
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// PriorityValue is an occupied slot of a BACnet priority array
+type PriorityValue struct {
+	Priority int    `json:"priority"`
+	Value    string `json:"value"`
 }
 type SBPointsResponse struct {
 	Points []SBPoint `json:"data"`
@@ -25,6 +53,16 @@ type SBPointsResponse struct {
 type SBPointResponse struct {
 	Point SBPoint `json:"data"`
 }
+type SBPointsRawResponse struct {
+	Points []SBPointRaw `json:"data"`
+}
+type SBPointRawResponse struct {
+	Point SBPointRaw `json:"data"`
+}
+type SBPointRaw struct {
+	ID         string                 `json:"id"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
 type SBPoint struct {
 	ID         string            `json:"id"`
 	Attributes SBPointAttributes `json:"attributes"`
@@ -36,13 +74,80 @@ type SBPointAttributes struct {
 	PointValue       SBPointValue            `json:"pointValue"`
 }
 type SBPointSystemAttributes struct {
-	CurStatus   string `json:"curStatus"`
-	Description string `json:"description"`
-	Writable    string `json:"writable"`
+	CurStatus       string   `json:"curStatus"`
+	Description     string   `json:"description"`
+	Writable        string   `json:"writable"`
+	Units           string   `json:"units"`
+	MinPresValue    SBNumber `json:"minPresValue"`
+	MaxPresValue    SBNumber `json:"maxPresValue"`
+	Resolution      SBNumber `json:"resolution"`
+	StateText       []string `json:"stateText"`
+	InactiveText    string   `json:"inactiveText"`
+	ActiveText      string   `json:"activeText"`
+	ObjectReference string   `json:"objectReference"`
+}
+
+// SBNumber is a number of the API payload that may be absent, null or sent as a string
+type SBNumber struct {
+	Value float64
+	Valid bool
+}
+
+// UnmarshalJSON accepts a number, a string holding a number or null
+func (n *SBNumber) UnmarshalJSON(data []byte) error {
+
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*n = SBNumber{}
+		return nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s: %w", string(data), err)
+	}
+	*n = SBNumber{Value: f, Valid: true}
+	return nil
+
+}
+
+// MarshalJSON writes the number, or null when it is not valid
+func (n SBNumber) MarshalJSON() ([]byte, error) {
+
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Value)
+
 }
+
+// ptr returns a pointer to the number, or nil when it is not valid
+func (n SBNumber) ptr() *float64 {
+
+	if !n.Valid {
+		return nil
+	}
+	f := n.Value
+	return &f
+
+}
+
+// NewSBNumber returns the SBNumber for an optional value
+func NewSBNumber(f *float64) SBNumber {
+
+	if f == nil {
+		return SBNumber{}
+	}
+	return SBNumber{Value: *f, Valid: true}
+
+}
+
 type SBPointValue struct {
 	Value     string `json:"value"`
 	Timestamp string `json:"timestamp"`
+	// PriorityArray holds the 16 slots of the BACnet priority array, starting with priority 1. Empty slots are null.
+	PriorityArray []interface{} `json:"priorityArray,omitempty"`
+	// Priority is the priority of the slot that controls the present value, when the API reports it
+	Priority SBNumber `json:"priority"`
 }
 
 type PointHistory struct {
@@ -79,99 +184,229 @@ type SBPointCommandAttributes struct {
 }
 type SBPointCommandPointValue struct {
 	Value string `json:"value"`
+	// Priority is the BACnet priority (1 to 16) of the command. Zero leaves the priority to the API.
+	Priority int `json:"priority,omitempty"`
+	// Release relinquishes the command at Priority. It is sent as a null value.
+	Release bool `json:"-"`
+}
+
+// MarshalJSON writes a release as a null value
+func (v SBPointCommandPointValue) MarshalJSON() ([]byte, error) {
+
+	pointValue := struct {
+		Value    *string `json:"value"`
+		Priority int     `json:"priority,omitempty"`
+	}{Priority: v.Priority}
+	if !v.Release {
+		pointValue.Value = &v.Value
+	}
+	return json.Marshal(pointValue)
+
+}
+
+// UnmarshalJSON reads a null value as a release
+func (v *SBPointCommandPointValue) UnmarshalJSON(data []byte) error {
+
+	pointValue := struct {
+		Value    *string `json:"value"`
+		Priority int     `json:"priority"`
+	}{}
+	if err := json.Unmarshal(data, &pointValue); err != nil {
+		return err
+	}
+	*v = SBPointCommandPointValue{Priority: pointValue.Priority, Release: pointValue.Value == nil}
+	if pointValue.Value != nil {
+		v.Value = *pointValue.Value
+	}
+	return nil
+
 }
 
 // returns an array of points that are associated with a particular device
 func GetPointsByDevice(session *Session, device *Device) ([]Point, error) {
+	return GetPointsByDeviceWithContext(context.Background(), session, device)
+}
 
-	points := make([]Point, 0)
+// GetPointsByDeviceWithContext is like GetPointsByDevice but uses the given context for the API call and any token refresh
+func GetPointsByDeviceWithContext(ctx context.Context, session *Session, device *Device) ([]Point, error) {
+	return session.apiClient().getPointsByDevice(ctx, session, device)
+}
 
-	// make sure session is initialized
-	if !session.IsInitialized {
-		return points, errors.New("session is not initialized")
+// GetPointsByDevice returns an array of points that are associated with a particular device
+func (c *Client) GetPointsByDevice(device *Device) ([]Point, error) {
+	return c.GetPointsByDeviceWithContext(context.Background(), device)
+}
+
+// GetPointsByDeviceWithContext is like GetPointsByDevice but uses the given context for the API call and any token refresh
+func (c *Client) GetPointsByDeviceWithContext(ctx context.Context, device *Device) ([]Point, error) {
+	return c.getPointsByDevice(ctx, c.session, device)
+}
+
+// GetPointsByDevicePages calls fn with each page of points that are associated with a particular device. Iteration stops
+// when fn returns false or the last page has been read.
+func GetPointsByDevicePages(session *Session, device *Device, fn func(points []Point, lastPage bool) bool) error {
+	return GetPointsByDevicePagesWithContext(context.Background(), session, device, fn)
+}
+
+// GetPointsByDevicePagesWithContext is like GetPointsByDevicePages but uses the given context for the API calls and any token refresh
+func GetPointsByDevicePagesWithContext(ctx context.Context, session *Session, device *Device, fn func(points []Point, lastPage bool) bool) error {
+	return session.apiClient().getPointsByDevicePages(ctx, session, device, fn)
+}
+
+// GetPointsByDevicePages calls fn with each page of points that are associated with a particular device
+func (c *Client) GetPointsByDevicePages(device *Device, fn func(points []Point, lastPage bool) bool) error {
+	return c.GetPointsByDevicePagesWithContext(context.Background(), device, fn)
+}
+
+// GetPointsByDevicePagesWithContext is like GetPointsByDevicePages but uses the given context for the API calls and any token refresh
+func (c *Client) GetPointsByDevicePagesWithContext(ctx context.Context, device *Device, fn func(points []Point, lastPage bool) bool) error {
+	return c.getPointsByDevicePages(ctx, c.session, device, fn)
+}
+
+func (c *Client) getPointsByDevice(ctx context.Context, session *Session, device *Device) ([]Point, error) {
+
+	points := make([]Point, 0)
+	err := c.getPointsByDevicePages(ctx, session, device, func(page []Point, lastPage bool) bool {
+		points = append(points, page...)
+		return true
+	})
+	if err != nil {
+		return make([]Point, 0), err
 	}
 
-	// make sure you have the required environment variable
-	endpoint := os.Getenv("BUILDINGX_ENDPOINT")
-	if endpoint == "" {
-		return points, errors.New("missing buildingx api endpoint")
+	return points, nil
+
+}
+func (c *Client) getPointsByDevicePages(ctx context.Context, session *Session, device *Device, fn func([]Point, bool) bool) error {
+
+	// make sure session is initialized
+	if !session.usable() {
+		return ErrSessionNotInitialized
 	}
 
 	// create the API request
 	path := fmt.Sprintf("devices/%s/points?field[Point]=pointValue", device.ID)
 	req := APIRequest{
-		Partition: session.Partition,
-		JWT:       session.JWT,
+		Name:      "GetPointsByDevice",
 		Path:      path,
 		Operation: GET,
 	}
 
-	// make the API call
-	resp, err := MakeRESTCall(req)
-	if err != nil {
-		return points, errors.New("error making REST call: " + err.Error())
-	}
+	// make the API calls, one per page
+	return c.pagedCall(ctx, session, req, func(payload []byte, lastPage bool) (bool, error) {
+		points, err := parsePointsJSON(payload)
+		if err != nil {
+			return false, err
+		}
+		return fn(points, lastPage), nil
+	})
+
+}
+func parsePointsJSON(payload []byte) ([]Point, error) {
+
+	points := make([]Point, 0)
 
 	// Unmarshal the native points response payload
 	sbPointsResponse := SBPointsResponse{}
-	if err := json.Unmarshal(resp, &sbPointsResponse); err != nil {
-		return points, errors.New("Error parsing API response. String submitted: " + string(resp))
+	if err := json.Unmarshal(payload, &sbPointsResponse); err != nil {
+		return points, errors.New("Error parsing API response. String submitted: " + string(payload))
 	}
 
-	//TODO: create a common point mapping function for this function and GetSinglePoint
-	for _, sbPoint := range sbPointsResponse.Points {
+	// and again to keep every attribute, including those that are not modeled
+	sbPointsRawResponse := SBPointsRawResponse{}
+	if err := json.Unmarshal(payload, &sbPointsRawResponse); err != nil {
+		return points, errors.New("Error parsing API response. String submitted: " + string(payload))
+	}
 
-		// deliberately ignoring the error here as we don't know what to do with it
-		timeStamp, _ := time.Parse(time.RFC3339, sbPoint.Attributes.PointValue.Timestamp)
-		writableString := sbPoint.Attributes.SystemAttributes.Writable
-		writable := false
-		if writableString == "m:" {
-			writable = true
-		}
+	for i, sbPoint := range sbPointsResponse.Points {
+		points = append(points, mapPoint(sbPoint, sbPointsRawResponse.Points[i].Attributes))
+	}
 
-		point := Point{
-			ID:          sbPoint.ID,
-			Name:        sbPoint.Attributes.Name,
-			Description: sbPoint.Attributes.SystemAttributes.Description,
-			DataType:    sbPoint.Attributes.DataType,
-			Writable:    writable,
-			Status:      sbPoint.Attributes.SystemAttributes.CurStatus,
-			StringValue: sbPoint.Attributes.PointValue.Value,
-			Timestamp:   timeStamp,
-		}
+	return points, nil
+}
 
-		points = append(points, point)
+// mapPoint maps the native point structure to our point structure
+func mapPoint(sbPoint SBPoint, attributes map[string]interface{}) Point {
+
+	// deliberately ignoring the error here as we don't know what to do with it
+	timeStamp, _ := time.Parse(time.RFC3339, sbPoint.Attributes.PointValue.Timestamp)
+	system := sbPoint.Attributes.SystemAttributes
+
+	point := Point{
+		ID:              sbPoint.ID,
+		Name:            sbPoint.Attributes.Name,
+		Description:     system.Description,
+		DataType:        sbPoint.Attributes.DataType,
+		Writable:        system.Writable == "m:",
+		Status:          system.CurStatus,
+		StringValue:     sbPoint.Attributes.PointValue.Value,
+		Timestamp:       timeStamp,
+		Units:           system.Units,
+		Min:             system.MinPresValue.ptr(),
+		Max:             system.MaxPresValue.ptr(),
+		Resolution:      system.Resolution.ptr(),
+		StateTexts:      system.StateText,
+		BACnetReference: system.ObjectReference,
+		Attributes:      attributes,
+	}
+	if len(point.StateTexts) == 0 && (system.InactiveText != "" || system.ActiveText != "") {
+		point.StateTexts = []string{system.InactiveText, system.ActiveText}
 	}
 
-	return points, nil
+	// the active priority is the reported one or else the highest occupied slot
+	for i, slot := range sbPoint.Attributes.PointValue.PriorityArray {
+		if slot != nil {
+			point.PriorityArray = append(point.PriorityArray, PriorityValue{Priority: i + 1, Value: formatRaw(slot)})
+		}
+	}
+	if sbPoint.Attributes.PointValue.Priority.Valid {
+		point.Priority = int(sbPoint.Attributes.PointValue.Priority.Value)
+	} else if len(point.PriorityArray) > 0 {
+		point.Priority = point.PriorityArray[0].Priority
+	}
+
+	return point
+
 }
 func GetSinglePoint(session *Session, id string) (Point, error) {
+	return GetSinglePointWithContext(context.Background(), session, id)
+}
+
+// GetSinglePointWithContext is like GetSinglePoint but uses the given context for the API call and any token refresh
+func GetSinglePointWithContext(ctx context.Context, session *Session, id string) (Point, error) {
+	return session.apiClient().getSinglePoint(ctx, session, id)
+}
+
+// GetSinglePoint returns a single point by its id
+func (c *Client) GetSinglePoint(id string) (Point, error) {
+	return c.GetSinglePointWithContext(context.Background(), id)
+}
+
+// GetSinglePointWithContext is like GetSinglePoint but uses the given context for the API call and any token refresh
+func (c *Client) GetSinglePointWithContext(ctx context.Context, id string) (Point, error) {
+	return c.getSinglePoint(ctx, c.session, id)
+}
+
+func (c *Client) getSinglePoint(ctx context.Context, session *Session, id string) (Point, error) {
 
 	point := Point{}
 	// make sure session is initialized
-	if !session.IsInitialized {
-		return point, errors.New("session is not initialized")
-	}
-
-	// make sure you have the required environment variable
-	endpoint := os.Getenv("BUILDINGX_ENDPOINT")
-	if endpoint == "" {
-		return point, errors.New("missing buildingx api endpoint")
+	if !session.usable() {
+		return point, ErrSessionNotInitialized
 	}
 
 	// create the API request
 	path := fmt.Sprintf("points/%s?field[Point]=pointValue", id)
 	req := APIRequest{
-		Partition: session.Partition,
-		JWT:       session.JWT,
+		Name:      "GetSinglePoint",
 		Path:      path,
 		Operation: GET,
 	}
 
 	// make the API call
-	resp, err := MakeRESTCall(req)
+	resp, err := c.call(ctx, session, req)
 	if err != nil {
-		return point, errors.New("error making REST call: " + err.Error())
+		return point, fmt.Errorf("error making REST call: %w", err)
 	}
 
 	// Unmarshal the native point response payload
@@ -179,39 +414,54 @@ func GetSinglePoint(session *Session, id string) (Point, error) {
 	if err := json.Unmarshal(resp, &sbPointResponse); err != nil {
 		return point, errors.New("Error parsing API response. String submitted: " + string(resp))
 	}
+	sbPointRawResponse := SBPointRawResponse{}
+	if err := json.Unmarshal(resp, &sbPointRawResponse); err != nil {
+		return point, errors.New("Error parsing API response. String submitted: " + string(resp))
+	}
 
-	// map the native point structure to our point structure
-
-	// deliberately ignoring the error here as we don't know what to do with it
-	timeStamp, _ := time.Parse(time.RFC3339, sbPointResponse.Point.Attributes.PointValue.Timestamp)
-	writableString := sbPointResponse.Point.Attributes.SystemAttributes.Writable
-	writable := false
-	if writableString == "m:" {
-		writable = true
-	}
-
-	point.ID = sbPointResponse.Point.ID
-	point.Name = sbPointResponse.Point.Attributes.Name
-	point.Description = sbPointResponse.Point.Attributes.SystemAttributes.Description
-	point.DataType = sbPointResponse.Point.Attributes.DataType
-	point.Writable = writable
-	point.Status = sbPointResponse.Point.Attributes.SystemAttributes.CurStatus
-	point.StringValue = sbPointResponse.Point.Attributes.PointValue.Value
-	point.Timestamp = timeStamp
+	point = mapPoint(sbPointResponse.Point, sbPointRawResponse.Point.Attributes)
 
 	// all is well. return the point
 	return point, nil
 
 }
-func CommandPointValue(session *Session, point *Point, value string) error {
+func CommandPointValue(session *Session, point *Point, value string, opts ...CommandOption) error {
+	return CommandPointValueWithContext(context.Background(), session, point, value, opts...)
+}
 
-	if !point.Writable {
-		return errors.New("point is not writable")
+// CommandPointValueWithContext is like CommandPointValue but uses the given context for the API call and any token refresh
+func CommandPointValueWithContext(ctx context.Context, session *Session, point *Point, value string, opts ...CommandOption) error {
+	return session.apiClient().commandPointValue(ctx, session, point, value, opts...)
+}
+
+// CommandPointValue sets the value of a writable point. The value is validated against the data type, limits
+// and states of the point before it is sent, unless the Force option is given.
+func (c *Client) CommandPointValue(point *Point, value string, opts ...CommandOption) error {
+	return c.CommandPointValueWithContext(context.Background(), point, value, opts...)
+}
+
+// CommandPointValueWithContext is like CommandPointValue but uses the given context for the API call and any token refresh
+func (c *Client) CommandPointValueWithContext(ctx context.Context, point *Point, value string, opts ...CommandOption) error {
+	return c.commandPointValue(ctx, c.session, point, value, opts...)
+}
+
+func (c *Client) commandPointValue(ctx context.Context, session *Session, point *Point, value string, opts ...CommandOption) error {
+
+	options := newCommandOptions(opts)
+	if err := options.check(point, value); err != nil {
+		return err
 	}
 
+	return c.sendCommand(ctx, session, "CommandPointValue", point, SBPointCommandPointValue{Value: value, Priority: options.priority})
+
+}
+
+// sendCommand sends the PATCH request that commands or releases a point
+func (c *Client) sendCommand(ctx context.Context, session *Session, name string, point *Point, pointValue SBPointCommandPointValue) error {
+
 	command := SBPointCommand{}
 	command.Data.ID = point.ID
-	command.Data.Attributes.PointValue.Value = value
+	command.Data.Attributes.PointValue = pointValue
 
 	requestBytes, _ := json.Marshal(command)
 	request := bytes.NewReader(requestBytes)
@@ -219,52 +469,143 @@ func CommandPointValue(session *Session, point *Point, value string) error {
 	// create the API request
 	path := fmt.Sprintf("points/%s?field[Point]=pointValue", point.ID)
 	req := APIRequest{
-		Partition: session.Partition,
-		JWT:       session.JWT,
+		Name:      name,
 		Path:      path,
 		Operation: PATCH,
 		Body:      *request,
 	}
 
 	// make the API call
-	_, err := MakeRESTCall(req)
+	_, err := c.call(ctx, session, req)
+	if errors.Is(err, ErrDryRun) {
+		return err
+	}
 	if err != nil {
-		return errors.New("error making REST call: " + err.Error())
+		return fmt.Errorf("error making REST call: %w", err)
 	}
 
 	// all is well
 	return nil
 
+}
+
+// ReleasePointCommand relinquishes the command at the priority given with the Priority option, so that a
+// lower priority command or the relinquish default controls the point again. Without the Priority option
+// the command is released at the priority the API uses for commands.
+func ReleasePointCommand(session *Session, point *Point, opts ...CommandOption) error {
+	return ReleasePointCommandWithContext(context.Background(), session, point, opts...)
+}
+
+// ReleasePointCommandWithContext is like ReleasePointCommand but uses the given context for the API call and any token refresh
+func ReleasePointCommandWithContext(ctx context.Context, session *Session, point *Point, opts ...CommandOption) error {
+	return session.apiClient().releasePointCommand(ctx, session, point, opts...)
+}
+
+// ReleasePointCommand relinquishes the command at the priority given with the Priority option
+func (c *Client) ReleasePointCommand(point *Point, opts ...CommandOption) error {
+	return c.ReleasePointCommandWithContext(context.Background(), point, opts...)
+}
+
+// ReleasePointCommandWithContext is like ReleasePointCommand but uses the given context for the API call and any token refresh
+func (c *Client) ReleasePointCommandWithContext(ctx context.Context, point *Point, opts ...CommandOption) error {
+	return c.releasePointCommand(ctx, c.session, point, opts...)
+}
+
+func (c *Client) releasePointCommand(ctx context.Context, session *Session, point *Point, opts ...CommandOption) error {
+
+	if !point.Writable {
+		return ErrPointNotWritable
+	}
+
+	options := newCommandOptions(opts)
+	if err := options.validPriority(); err != nil {
+		return err
+	}
+
+	return c.sendCommand(ctx, session, "ReleasePointCommand", point, SBPointCommandPointValue{Priority: options.priority, Release: true})
+
 }
 func GetPointHistory(session *Session, point *Point, start, end time.Time) ([]PointHistory, error) {
+	return GetPointHistoryWithContext(context.Background(), session, point, start, end)
+}
 
-	history := make([]PointHistory, 0)
+// GetPointHistoryWithContext is like GetPointHistory but uses the given context for the API call and any token refresh
+func GetPointHistoryWithContext(ctx context.Context, session *Session, point *Point, start, end time.Time) ([]PointHistory, error) {
+	return session.apiClient().getPointHistory(ctx, session, point, start, end)
+}
+
+// GetPointHistory returns the recorded values of a point between start and end
+func (c *Client) GetPointHistory(point *Point, start, end time.Time) ([]PointHistory, error) {
+	return c.GetPointHistoryWithContext(context.Background(), point, start, end)
+}
+
+// GetPointHistoryWithContext is like GetPointHistory but uses the given context for the API call and any token refresh
+func (c *Client) GetPointHistoryWithContext(ctx context.Context, point *Point, start, end time.Time) ([]PointHistory, error) {
+	return c.getPointHistory(ctx, c.session, point, start, end)
+}
+
+// GetPointHistoryPages calls fn with each page of recorded values of a point between start and end. Iteration stops
+// when fn returns false or the last page has been read.
+func GetPointHistoryPages(session *Session, point *Point, start, end time.Time, fn func(history []PointHistory, lastPage bool) bool) error {
+	return GetPointHistoryPagesWithContext(context.Background(), session, point, start, end, fn)
+}
+
+// GetPointHistoryPagesWithContext is like GetPointHistoryPages but uses the given context for the API calls and any token refresh
+func GetPointHistoryPagesWithContext(ctx context.Context, session *Session, point *Point, start, end time.Time, fn func(history []PointHistory, lastPage bool) bool) error {
+	return session.apiClient().getPointHistoryPages(ctx, session, point, start, end, fn)
+}
 
-	// make sure you have the required environment variable
-	endpoint := os.Getenv("BUILDINGX_ENDPOINT")
-	if endpoint == "" {
-		return history, errors.New("missing buildingx api endpoint")
+// GetPointHistoryPages calls fn with each page of recorded values of a point between start and end
+func (c *Client) GetPointHistoryPages(point *Point, start, end time.Time, fn func(history []PointHistory, lastPage bool) bool) error {
+	return c.GetPointHistoryPagesWithContext(context.Background(), point, start, end, fn)
+}
+
+// GetPointHistoryPagesWithContext is like GetPointHistoryPages but uses the given context for the API calls and any token refresh
+func (c *Client) GetPointHistoryPagesWithContext(ctx context.Context, point *Point, start, end time.Time, fn func(history []PointHistory, lastPage bool) bool) error {
+	return c.getPointHistoryPages(ctx, c.session, point, start, end, fn)
+}
+
+func (c *Client) getPointHistory(ctx context.Context, session *Session, point *Point, start, end time.Time) ([]PointHistory, error) {
+
+	history := make([]PointHistory, 0)
+	err := c.getPointHistoryPages(ctx, session, point, start, end, func(page []PointHistory, lastPage bool) bool {
+		history = append(history, page...)
+		return true
+	})
+	if err != nil {
+		return make([]PointHistory, 0), err
 	}
 
+	return history, nil
+
+}
+func (c *Client) getPointHistoryPages(ctx context.Context, session *Session, point *Point, start, end time.Time, fn func([]PointHistory, bool) bool) error {
+
 	// create the API request
-	path := fmt.Sprintf("points/%s/values?filter[timestamp][from]=%s&[timestamp][to]=%s", point.ID, start.Format(time.RFC3339), end.Format(time.RFC3339))
 	req := APIRequest{
-		Partition: session.Partition,
-		JWT:       session.JWT,
-		Path:      path,
+		Name:      "GetPointHistory",
+		Path:      pointHistoryPath(point.ID, start, end, 0),
 		Operation: GET,
 	}
 
-	// make the API call
-	resp, err := MakeRESTCall(req)
-	if err != nil {
-		return history, errors.New("error making REST call: " + err.Error())
-	}
+	// make the API calls, one per page
+	return c.pagedCall(ctx, session, req, func(payload []byte, lastPage bool) (bool, error) {
+		history, err := parsePointHistoryJSON(payload)
+		if err != nil {
+			return false, err
+		}
+		return fn(history, lastPage), nil
+	})
+
+}
+func parsePointHistoryJSON(payload []byte) ([]PointHistory, error) {
+
+	history := make([]PointHistory, 0)
 
 	// Unmarshal the native point response payload
 	sbPointHistoryResponse := SBPointHistoryResponse{}
-	if err := json.Unmarshal(resp, &sbPointHistoryResponse); err != nil {
-		return history, errors.New("Error parsing API response. String submitted: " + string(resp))
+	if err := json.Unmarshal(payload, &sbPointHistoryResponse); err != nil {
+		return history, errors.New("Error parsing API response. String submitted: " + string(payload))
 	}
 
 	for _, sbHistory := range sbPointHistoryResponse.Data {
@@ -2,7 +2,8 @@ package buildingx
 
 import (
 	"context"
-	"os"
+	"fmt"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
@@ -15,11 +16,8 @@ func TestGetPoints(t *testing.T) {
 	ctx := context.Background()
 	ctx, _ = xray.BeginSegment(ctx, "TestGetPoints")
 
-	// first make sure you have a partition ID (from environment variable)
-	partitionID := os.Getenv("BUILDINGX_PARTITION_ID")
-	if partitionID == "" {
-		t.Fatal("unable to find partition ID in environment variable")
-	}
+	// first make sure you have a partition ID and credentials (the test is skipped without them)
+	partitionID := integrationPartition(t)
 
 	// initialize the session (uses credentials to authenticate and produce a JWT)
 	session := Session{}
@@ -74,11 +72,8 @@ func TestGetSinglePoint(t *testing.T) {
 	ctx := context.Background()
 	ctx, _ = xray.BeginSegment(ctx, "TestGetSinglePoint")
 
-	// first make sure you have a partition ID (from environment variable)
-	partitionID := os.Getenv("BUILDINGX_PARTITION_ID")
-	if partitionID == "" {
-		t.Fatal("unable to find partition ID in environment variable")
-	}
+	// first make sure you have a partition ID and credentials (the test is skipped without them)
+	partitionID := integrationPartition(t)
 
 	// initialize the session (uses credentials to authenticate and produce a JWT)
 	session := Session{}
@@ -145,11 +140,8 @@ func TestCommandPoint(t *testing.T) {
 	ctx := context.Background()
 	ctx, _ = xray.BeginSegment(ctx, "TestCommandPoint")
 
-	// first make sure you have a partition ID (from environment variable)
-	partitionID := os.Getenv("BUILDINGX_PARTITION_ID")
-	if partitionID == "" {
-		t.Fatal("unable to find partition ID in environment variable")
-	}
+	// first make sure you have a partition ID and credentials (the test is skipped without them)
+	partitionID := integrationPartition(t)
 
 	// initialize the session (uses credentials to authenticate and produce a JWT)
 	session := Session{}
@@ -223,11 +215,8 @@ func TestGetPointHistory(t *testing.T) {
 	ctx := context.Background()
 	ctx, _ = xray.BeginSegment(ctx, "TestGetPointHistory")
 
-	// first make sure you have a partition ID (from environment variable)
-	partitionID := os.Getenv("BUILDINGX_PARTITION_ID")
-	if partitionID == "" {
-		t.Fatal("unable to find partition ID in environment variable")
-	}
+	// first make sure you have a partition ID and credentials (the test is skipped without them)
+	partitionID := integrationPartition(t)
 
 	// initialize the session (uses credentials to authenticate and produce a JWT)
 	session := Session{}
@@ -294,3 +283,54 @@ func TestGetPointHistory(t *testing.T) {
 	})
 
 }
+
+func TestPointMetadata(t *testing.T) {
+
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"id":"setpoint","attributes":{"name":"RoomTempSp","dataType":"number","externalId":"ext-1",
+				"systemAttributes":{"curStatus":"ok","writable":"m:","units":"°F","minPresValue":55,"maxPresValue":"85","resolution":0.5,"objectReference":"2098177/analogValue:3"},
+				"pointValue":{"value":"72","timestamp":"2022-05-01T12:00:00Z"}}},
+			{"id":"fan","attributes":{"name":"FanCmd","dataType":"boolean",
+				"systemAttributes":{"inactiveText":"Off","activeText":"On","minPresValue":null},
+				"pointValue":{"value":"true"}}},
+			{"id":"mode","attributes":{"name":"Mode","dataType":"enum",
+				"systemAttributes":{"stateText":["Occupied","Unoccupied"]},
+				"pointValue":{"value":"2"}}}
+		]}`)
+	}))
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+	points, err := client.GetPointsByDevice(&Device{ID: "device"})
+	if err != nil {
+		t.Fatal("error getting points: ", err.Error())
+	}
+
+	t.Run("limits-units-and-reference", func(t *testing.T) {
+		setpoint := points[0]
+		assert.Equal(t, "°F", setpoint.Units)
+		assert.Equal(t, 55.0, *setpoint.Min)
+		assert.Equal(t, 85.0, *setpoint.Max)
+		assert.Equal(t, 0.5, *setpoint.Resolution)
+		assert.Equal(t, "2098177/analogValue:3", setpoint.BACnetReference)
+		assert.Equal(t, "72 °F", setpoint.FormattedValue())
+	})
+	t.Run("raw-attributes-are-kept", func(t *testing.T) {
+		assert.Equal(t, "ext-1", points[0].Attributes["externalId"])
+	})
+	t.Run("state-texts", func(t *testing.T) {
+		fan, mode := points[1], points[2]
+		assert.Nil(t, fan.Min)
+		assert.Equal(t, []string{"Off", "On"}, fan.StateTexts)
+		assert.Equal(t, "On", fan.FormattedValue())
+		text, ok := mode.StateText(1)
+		assert.True(t, ok)
+		assert.Equal(t, "Occupied", text)
+		assert.Equal(t, "Unoccupied", mode.FormattedValue())
+		_, ok = mode.StateText(3)
+		assert.False(t, ok)
+	})
+
+}
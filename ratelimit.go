@@ -0,0 +1,125 @@
+package buildingx
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit paces the calls a Client makes to the Operations API. The limits are shared by every goroutine
+// and session using the client. The zero value applies no limits.
+type RateLimit struct {
+	// RequestsPerSecond is the sustained rate of the token bucket. Zero disables rate limiting.
+	RequestsPerSecond float64
+	// Burst is the number of calls that may be made at once before the rate applies. It defaults to 1.
+	Burst int
+	// MaxConcurrent caps the number of calls in flight at the same time. Zero means no cap.
+	MaxConcurrent int
+}
+
+// CallInfo describes a completed call to the Operations API. It is passed to the function set with WithCallObserver.
+type CallInfo struct {
+	Operation  Verb
+	Path       string
+	Attempts   int
+	Wait       time.Duration
+	Duration   time.Duration
+	StatusCode int
+	Err        error
+}
+
+// WithRateLimit sets the rate and concurrency limits of the client
+func WithRateLimit(limit RateLimit) Option {
+	return func(c *Config) { c.RateLimit = limit }
+}
+
+// WithCallObserver sets a function that is called after every call to the Operations API. Wait reports how
+// long the call waited for the rate and concurrency limits.
+func WithCallObserver(observer func(CallInfo)) Option {
+	return func(c *Config) { c.CallObserver = observer }
+}
+
+// limiter combines a token bucket with a cap on concurrent calls
+type limiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	slots chan struct{}
+}
+
+// newLimiter returns a limiter for the given limits, or nil when there is nothing to limit
+func newLimiter(limit RateLimit) *limiter {
+
+	if limit.RequestsPerSecond <= 0 && limit.MaxConcurrent <= 0 {
+		return nil
+	}
+
+	l := &limiter{rate: limit.RequestsPerSecond, burst: math.Max(float64(limit.Burst), 1)}
+	l.tokens = l.burst
+	l.last = time.Now()
+	if limit.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, limit.MaxConcurrent)
+	}
+	return l
+
+}
+
+// acquire waits for a token and a free slot. It returns how long it waited and a function that must be
+// called to release the slot once the call completes.
+func (l *limiter) acquire(ctx context.Context) (time.Duration, func(), error) {
+
+	if l == nil {
+		return 0, func() {}, nil
+	}
+	start := time.Now()
+
+	if l.rate > 0 {
+		// reserve a token, going into debt if none is available, and wait until the debt is paid
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		l.tokens--
+		wait := time.Duration(0)
+		if l.tokens < 0 {
+			wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				l.cancel()
+				return time.Since(start), nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+
+	if l.slots == nil {
+		return time.Since(start), func() {}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return time.Since(start), nil, ctx.Err()
+	}
+	return time.Since(start), func() { <-l.slots }, nil
+
+}
+
+// cancel returns a reserved token that was not used
+func (l *limiter) cancel() {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = math.Min(l.burst, l.tokens+1)
+
+}
@@ -0,0 +1,75 @@
+package buildingx
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+
+	// track the highest number of requests in flight at the same time
+	var inFlight, maxInFlight int32
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, `{"data":{"id":"point-1"}}`)
+	}))
+
+	t.Run("calls-are-paced", func(t *testing.T) {
+		var mu sync.Mutex
+		var waited time.Duration
+		client, err := NewClient(config,
+			WithRateLimit(RateLimit{RequestsPerSecond: 50, Burst: 1}),
+			WithCallObserver(func(info CallInfo) {
+				mu.Lock()
+				defer mu.Unlock()
+				waited += info.Wait
+			}))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			if _, err := client.GetSinglePoint("point-1"); err != nil {
+				t.Fatal("error getting point: ", err.Error())
+			}
+		}
+		// the first call is free, the remaining four wait up to 20ms each
+		assert.GreaterOrEqual(t, int64(time.Since(start)), int64(80*time.Millisecond))
+		assert.Greater(t, int64(waited), int64(0))
+	})
+	t.Run("concurrency-is-capped", func(t *testing.T) {
+		atomic.StoreInt32(&maxInFlight, 0)
+		client, err := NewClient(config, WithRateLimit(RateLimit{MaxConcurrent: 2}))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.GetSinglePoint("point-1")
+				assert.Nil(t, err)
+			}()
+		}
+		wg.Wait()
+		assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+	})
+
+}
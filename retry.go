@@ -0,0 +1,161 @@
+package buildingx
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how a Client retries calls that fail for transient reasons. The zero value
+// disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. A value below 2 disables retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles with every attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the computed delay. A Retry-After header sent by the API is honored even when it is longer.
+	MaxDelay time.Duration
+	// Jitter is the fraction (0 to 1) of each delay that is randomized, so that many clients do not retry in lockstep
+	Jitter float64
+	// RetryableStatuses lists the HTTP status codes that are retried. When empty, 408, 429, 500, 502, 503 and 504 are retried.
+	RetryableStatuses []int
+	// RetryNetworkErrors retries calls that failed without a response because of a network timeout or a refused,
+	// reset or dropped connection. Other network errors, such as certificate failures, are not retried.
+	RetryNetworkErrors bool
+	// RetryCommands allows PATCH requests, which command equipment and are not idempotent, to be retried
+	RetryCommands bool
+}
+
+// DefaultRetryPolicy returns a policy of up to 4 attempts with exponential backoff from 200ms to 5s and 50% jitter
+func DefaultRetryPolicy() RetryPolicy {
+
+	return RetryPolicy{
+		MaxAttempts:        4,
+		BaseDelay:          200 * time.Millisecond,
+		MaxDelay:           5 * time.Second,
+		Jitter:             0.5,
+		RetryNetworkErrors: true,
+	}
+
+}
+
+// WithRetryPolicy sets the policy used to retry calls that fail for transient reasons
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Config) { c.RetryPolicy = policy }
+}
+
+// shouldRetry reports whether a call that failed with err may be attempted again under the policy
+func (p RetryPolicy) shouldRetry(apiReq APIRequest, err error) bool {
+
+	if err == nil || (apiReq.Operation == PATCH && !p.RetryCommands) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if len(p.RetryableStatuses) == 0 {
+			return apiErr.Retryable()
+		}
+		for _, status := range p.RetryableStatuses {
+			if status == apiErr.StatusCode {
+				return true
+			}
+		}
+		return false
+	}
+
+	// anything else that is retryable failed without a response
+	return p.RetryNetworkErrors && IsRetryable(err)
+
+}
+
+// delay returns how long to wait before the given retry (1 for the first retry). A Retry-After value
+// sent with a 429 or 503 response takes precedence over the computed backoff.
+func (p RetryPolicy) delay(retry int, err error) time.Duration {
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 && (apiErr.StatusCode == 429 || apiErr.StatusCode == 503) {
+		return apiErr.RetryAfter
+	}
+
+	d := float64(p.BaseDelay) * math.Pow(2, float64(retry-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		d = d*(1-jitter) + d*jitter*rand.Float64()
+	}
+	return time.Duration(d)
+
+}
+
+// parseRetryAfter reads a Retry-After header, which holds either a number of seconds or an HTTP date
+func parseRetryAfter(header string) time.Duration {
+
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+
+}
+
+// makeRESTCallWithRetry makes the API request, retrying it according to the client retry policy. Every
+// attempt waits its turn under the client rate and concurrency limits.
+func (c *Client) makeRESTCallWithRetry(ctx context.Context, apiReq APIRequest) ([]byte, int, error) {
+
+	policy := c.config.RetryPolicy
+	info := CallInfo{Operation: apiReq.Operation, Path: apiReq.Path}
+	start := time.Now()
+
+	resp, status, err := make([]byte, 0), 0, error(nil)
+	for attempt := 1; ; attempt++ {
+
+		info.Attempts = attempt
+		wait, release, limitErr := c.limiter.acquire(ctx)
+		info.Wait += wait
+		if limitErr != nil {
+			err = limitErr
+			break
+		}
+		resp, status, err = c.makeRESTCall(ctx, apiReq)
+		release()
+
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil || !policy.shouldRetry(apiReq, err) {
+			break
+		}
+
+		// wait for the backoff, unless the caller gives up first
+		timer := time.NewTimer(policy.delay(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+	}
+
+	if c.config.CallObserver != nil {
+		info.Duration = time.Since(start)
+		info.StatusCode = status
+		info.Err = err
+		c.config.CallObserver(info)
+	}
+	return resp, status, err
+
+}
@@ -0,0 +1,76 @@
+package buildingx
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy(t *testing.T) {
+
+	// fail the first two attempts of every request with a 503
+	var attempts int32
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"id":"point-1"}}`)
+	}))
+	policy := RetryPolicy{
+		MaxAttempts:        3,
+		BaseDelay:          time.Millisecond,
+		MaxDelay:           10 * time.Millisecond,
+		Jitter:             0.5,
+		RetryNetworkErrors: true,
+	}
+
+	t.Run("transient-failures-are-retried", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		client, err := NewClient(config, WithRetryPolicy(policy))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+		point, err := client.GetSinglePoint("point-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "point-1", point.ID)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	})
+	t.Run("commands-are-not-retried-by-default", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		client, err := NewClient(config, WithRetryPolicy(policy))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+		err = client.CommandPointValue(&Point{ID: "point-1", Writable: true}, "1")
+		assert.NotNil(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	})
+	t.Run("commands-are-retried-when-allowed", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		commandPolicy := policy
+		commandPolicy.RetryCommands = true
+		client, err := NewClient(config, WithRetryPolicy(commandPolicy))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+		err = client.CommandPointValue(&Point{ID: "point-1", Writable: true}, "1")
+		assert.Nil(t, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	})
+	t.Run("retry-after-is-honored", func(t *testing.T) {
+		err := &APIError{StatusCode: 429, RetryAfter: 3 * time.Second}
+		assert.Equal(t, 3*time.Second, policy.delay(1, err))
+		assert.Equal(t, 2*time.Second, parseRetryAfter("2"))
+	})
+	t.Run("backoff-is-capped", func(t *testing.T) {
+		d := policy.delay(10, &APIError{StatusCode: 500})
+		assert.LessOrEqual(t, int64(d), int64(policy.MaxDelay))
+		assert.GreaterOrEqual(t, int64(d), int64(policy.MaxDelay/2))
+	})
+
+}
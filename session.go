@@ -1,30 +1,67 @@
 package buildingx
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
 
+// tokenRefreshWindow is how long before its expiration a token is proactively refreshed
+const tokenRefreshWindow = 60 * time.Second
+
+// Session holds the partition and token used by every call to the Building X API. A single Session
+// may be shared by many goroutines; the token is refreshed ahead of its expiration and only one
+// refresh runs at a time.
 type Session struct {
+	// Deprecated: IsInitialized is written when the token is refreshed, so reading it directly races with a
+	// refresh. Use Initialized.
 	IsInitialized bool
-	Partition     string
-	JWT           string
+	// Deprecated: Partition is written when the session is initialized or invalidated, so reading it directly
+	// races with those calls. Use PartitionID.
+	Partition string
+	// Deprecated: JWT is replaced when the token is refreshed, so reading it directly races with a refresh and
+	// may return an expired token. Use Token.
+	JWT string
+
+	mu          sync.RWMutex
+	refreshMu   sync.Mutex
+	expiresAt   time.Time
+	tokenSource func(context.Context) (SBToken, error)
+	client      *Client
+	dryRun      bool
+	dryRunLog   *log.Logger
 }
 
 // Initialize valides the API credentials and gets an array of all available locations
 func (t *Session) Initialize(partition string) error {
+	return t.InitializeWithContext(context.Background(), partition)
+}
+
+// InitializeWithContext is like Initialize but the credential exchange is bound to the given context
+func (t *Session) InitializeWithContext(ctx context.Context, partition string) error {
 
 	if partition == "" {
 		t.Invalidate()
 		return errors.New("partition cannot be empty")
 	}
 
-	token, err := GetToken()
+	tkn, err := envClient().requestToken(ctx)
 	if err != nil {
 		t.Invalidate()
-		return errors.New("error while getting token: " + err.Error())
+		return fmt.Errorf("error while getting token: %w", err)
 	}
 
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	t.IsInitialized = true
-	t.JWT = token
 	t.Partition = partition
+	t.client = nil
+	t.tokenSource = func(ctx context.Context) (SBToken, error) { return envClient().requestToken(ctx) }
+	t.setToken(tkn)
 
 	return nil
 }
@@ -32,8 +69,178 @@ func (t *Session) Initialize(partition string) error {
 // Invalidate resets all session properties
 func (t *Session) Invalidate() {
 
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	t.IsInitialized = false
 	t.Partition = ""
 	t.JWT = ""
+	t.expiresAt = time.Time{}
+	t.tokenSource = nil
+	t.client = nil
+
+}
+
+// Initialized reports whether the session has obtained a token
+func (t *Session) Initialized() bool {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.IsInitialized
+
+}
+
+// PartitionID returns the partition the session calls the API for
+func (t *Session) PartitionID() string {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.Partition
+
+}
+
+// ExpiresAt returns the time at which the session token expires. A zero time means the expiration is unknown.
+func (t *Session) ExpiresAt() time.Time {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.expiresAt
+
+}
+
+// Token returns a valid token for the session, refreshing it first if it is about to expire
+func (t *Session) Token() (string, error) {
+	return t.TokenWithContext(context.Background())
+}
+
+// TokenWithContext is like Token but any refresh is bound to the given context
+func (t *Session) TokenWithContext(ctx context.Context) (string, error) {
+
+	t.mu.RLock()
+	token := t.JWT
+	stale := t.needsRefresh()
+	t.mu.RUnlock()
+
+	if stale {
+		if err := t.refresh(ctx, token); err != nil {
+			return "", err
+		}
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if !t.IsInitialized {
+		return "", ErrSessionNotInitialized
+	}
+	return t.JWT, nil
+
+}
+
+// Refresh unconditionally exchanges the API credentials for a new token
+func (t *Session) Refresh() error {
+	return t.RefreshWithContext(context.Background())
+}
+
+// RefreshWithContext is like Refresh but the credential exchange is bound to the given context
+func (t *Session) RefreshWithContext(ctx context.Context) error {
+
+	t.mu.RLock()
+	token := t.JWT
+	t.mu.RUnlock()
+
+	return t.refresh(ctx, token)
+
+}
+
+// refresh replaces the given stale token. If another goroutine already replaced it while this one
+// was waiting for its turn, the new token is kept and no additional credential exchange is made.
+func (t *Session) refresh(ctx context.Context, stale string) error {
+
+	t.refreshMu.Lock()
+	defer t.refreshMu.Unlock()
+
+	t.mu.RLock()
+	current := t.JWT
+	source := t.tokenSource
+	t.mu.RUnlock()
+
+	if current != stale {
+		return nil
+	}
+	if source == nil {
+		return errors.New("session cannot refresh its token because it was not created by Initialize or a Client")
+	}
+
+	t.mu.RLock()
+	partition := t.Partition
+	t.mu.RUnlock()
+
+	tkn, err := source(context.WithValue(ctx, partitionKey{}, partition))
+	t.apiClient().tokenRefreshed(ctx, partition, err)
+	if err != nil {
+		return fmt.Errorf("error while refreshing token: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.setToken(tkn)
+
+	return nil
+
+}
+
+// needsRefresh reports whether a token has yet to be obtained or is inside its refresh window. The
+// caller must hold mu.
+func (t *Session) needsRefresh() bool {
+
+	if t.tokenSource == nil {
+		return false
+	}
+	if !t.IsInitialized {
+		return true
+	}
+	if t.expiresAt.IsZero() {
+		return false
+	}
+	return time.Now().Add(tokenRefreshWindow).After(t.expiresAt)
+
+}
+
+// usable reports whether the session has a token or is able to obtain one
+func (t *Session) usable() bool {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.IsInitialized || t.tokenSource != nil
+
+}
+
+// apiClient returns the client the session is bound to, or a client configured from the environment
+func (t *Session) apiClient() *Client {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.client != nil {
+		return t.client
+	}
+	return envClient()
+
+}
+
+// setToken stores the token and computes its expiration. The caller must hold mu for writing.
+func (t *Session) setToken(tkn SBToken) {
+
+	t.IsInitialized = true
+	t.JWT = tkn.AccessToken
+	if tkn.Expiration > 0 {
+		t.expiresAt = time.Now().Add(time.Duration(tkn.Expiration) * time.Second)
+	} else {
+		t.expiresAt = time.Time{}
+	}
 
 }
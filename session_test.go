@@ -0,0 +1,105 @@
+package buildingx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionTokenRefresh(t *testing.T) {
+
+	var tokensIssued int32
+	authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&tokensIssued, 1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":3600,"token_type":"Bearer"}`, n)
+	}))
+	defer authServer.Close()
+
+	// reject the first token to exercise the 401 retry
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"errors":[{"status":"401","detail":"expired"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[]}`)
+	}))
+	defer apiServer.Close()
+
+	t.Setenv("BUILDINGX_CLIENT_ID", "id")
+	t.Setenv("BUILDINGX_CLIENT_SECRET", "secret")
+	t.Setenv("BUILDINGX_AUDIENCE", "audience")
+	t.Setenv("BUILDINGX_AUTH_URL", authServer.URL)
+	t.Setenv("BUILDINGX_ENDPOINT", apiServer.URL)
+
+	session := Session{}
+	err := session.Initialize("partition")
+	if err != nil {
+		t.Fatal("test failed while initializing session: ", err.Error())
+	}
+	assert.False(t, session.ExpiresAt().IsZero())
+
+	t.Run("refresh-ahead-of-expiry", func(t *testing.T) {
+		before, _ := session.Token()
+		// move the expiration inside the refresh window
+		session.mu.Lock()
+		session.expiresAt = time.Now().Add(tokenRefreshWindow / 2)
+		session.mu.Unlock()
+		after, err := session.Token()
+		if err != nil {
+			t.Fatal("error getting token: ", err.Error())
+		}
+		assert.NotEqual(t, before, after)
+	})
+	t.Run("concurrent-callers-share-one-refresh", func(t *testing.T) {
+		session.mu.RLock()
+		stale := session.JWT
+		session.mu.RUnlock()
+		issued := atomic.LoadInt32(&tokensIssued)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Nil(t, session.refresh(context.Background(), stale))
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, issued+1, atomic.LoadInt32(&tokensIssued))
+	})
+	t.Run("accessors-are-safe-during-refresh", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				assert.Nil(t, session.Refresh())
+			}()
+			go func() {
+				defer wg.Done()
+				assert.True(t, session.Initialized())
+				assert.Equal(t, "partition", session.PartitionID())
+			}()
+		}
+		wg.Wait()
+	})
+	t.Run("retry-once-after-401", func(t *testing.T) {
+		s := Session{}
+		atomic.StoreInt32(&tokensIssued, 0)
+		if err := s.Initialize("partition"); err != nil {
+			t.Fatal("test failed while initializing session: ", err.Error())
+		}
+		_, err := GetLocations(&s)
+		assert.Nil(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&tokensIssued))
+	})
+
+}
@@ -0,0 +1,68 @@
+package buildingx
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// operationKey marks a context that is already inside a logical operation, so that the API calls made for
+// each page of a collection are recorded under a single operation
+type operationKey struct{}
+
+// WithXRay enables AWS X-Ray instrumentation. When the context passed to a call carries an X-Ray segment,
+// each logical operation (GetLocations, CommandPointValue and so on) is recorded as a subsegment annotated
+// with the partition, path and status, and the HTTP requests are traced through xray.Client.
+func WithXRay() Option {
+	return func(c *Config) { c.XRay = true }
+}
+
+// operation runs fn as the named logical operation
+func (c *Client) operation(ctx context.Context, name string, session *Session, fn func(context.Context) error) error {
+
+	if name == "" {
+		name = "MakeRESTCall"
+	}
+	ctx = context.WithValue(ctx, operationKey{}, name)
+	if !c.config.XRay || xray.GetSegment(ctx) == nil {
+		return fn(ctx)
+	}
+
+	return xray.Capture(ctx, name, func(ctx context.Context) error {
+		if session != nil {
+			xray.AddAnnotation(ctx, "partition", session.PartitionID())
+		}
+		return fn(ctx)
+	})
+
+}
+
+// inOperation reports whether ctx is already inside a logical operation
+func inOperation(ctx context.Context) bool {
+	return ctx.Value(operationKey{}) != nil
+}
+
+// annotateCall records the path and status of an API call on the current X-Ray subsegment
+func (c *Client) annotateCall(ctx context.Context, path string, status int) {
+
+	if !c.config.XRay {
+		return
+	}
+	if seg := xray.GetSegment(ctx); seg != nil {
+		seg.AddAnnotation("path", path)
+		seg.AddAnnotation("status", status)
+	}
+
+}
+
+// httpClientFor returns the HTTP client for a request made with ctx, which is wrapped with xray.Client
+// when X-Ray is enabled and ctx is traced
+func (c *Client) httpClientFor(ctx context.Context) *http.Client {
+
+	if c.tracedHTTPClient != nil && xray.GetSegment(ctx) != nil {
+		return c.tracedHTTPClient
+	}
+	return c.httpClient
+
+}
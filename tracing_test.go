@@ -0,0 +1,84 @@
+package buildingx
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/strategy/sampling"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/stretchr/testify/assert"
+)
+
+// listenForSegments starts a fake X-Ray daemon and returns its address and a function that returns every
+// segment document it has received
+func listenForSegments(t *testing.T) (string, func() string) {
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("error starting fake x-ray daemon: ", err.Error())
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn.LocalAddr().String(), func() string {
+		received := make([]string, 0)
+		buffer := make([]byte, 64*1024)
+		for {
+			conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+			n, _, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return strings.Join(received, "\n")
+			}
+			received = append(received, string(buffer[:n]))
+		}
+	}
+
+}
+
+// sampleAll is a sampling strategy that traces every request, so tests don't depend on the reservoir of the
+// default strategy
+type sampleAll struct{}
+
+func (sampleAll) ShouldTrace(*sampling.Request) *sampling.Decision {
+	return &sampling.Decision{Sample: true}
+}
+
+func TestXRay(t *testing.T) {
+
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":"point-1"}}`)
+	}))
+	client, err := NewClient(config, WithXRay())
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+
+	t.Run("operation-subsegments", func(t *testing.T) {
+		daemonAddr, received := listenForSegments(t)
+		udpAddr, _ := net.ResolveUDPAddr("udp", daemonAddr)
+		emitter, _ := xray.NewDefaultEmitter(udpAddr)
+		ctx, err := xray.ContextWithConfig(context.Background(), xray.Config{DaemonAddr: daemonAddr, Emitter: emitter, SamplingStrategy: sampleAll{}})
+		if err != nil {
+			t.Fatal("error configuring x-ray: ", err.Error())
+		}
+		ctx, seg := xray.BeginSegment(ctx, "TestXRay")
+		_, err = client.GetSinglePointWithContext(ctx, "point-1")
+		seg.Close(err)
+		assert.Nil(t, err)
+
+		emitted := received()
+		assert.True(t, strings.Contains(emitted, `"name":"GetSinglePoint"`))
+		assert.True(t, strings.Contains(emitted, `"name":"GetToken"`))
+		assert.True(t, strings.Contains(emitted, `"partition":"partition"`))
+		assert.True(t, strings.Contains(emitted, `"status":200`))
+	})
+	t.Run("untraced-context", func(t *testing.T) {
+		_, err := client.GetSinglePoint("point-1")
+		assert.Nil(t, err)
+	})
+
+}
@@ -0,0 +1,103 @@
+package buildingx
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// Middleware wraps the round tripper used for every HTTP request made by a Client, including token requests.
+// It can add headers, log or measure requests and responses, or replace the transport entirely.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts an ordinary function to the http.RoundTripper interface
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req)
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// defaultHTTPClient is shared by the package-level functions so that connections are reused between calls
+var defaultHTTPClient = &http.Client{Timeout: defaultTimeout}
+
+// WithTransport sets the round tripper used for every request (ex: to use a proxy or custom TLS roots)
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Config) { c.Transport = transport }
+}
+
+// WithMiddleware appends middleware to the client. The first middleware is the outermost, so it sees
+// each request first and each response last.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *Config) { c.Middleware = append(c.Middleware, middleware...) }
+}
+
+// HeaderMiddleware adds the given headers to every request
+func HeaderMiddleware(headers http.Header) Middleware {
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			// requests must not be modified by a round tripper, so work on a copy
+			req = req.Clone(req.Context())
+			for key, values := range headers {
+				for _, value := range values {
+					req.Header.Add(key, value)
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}
+
+}
+
+// LoggingMiddleware logs the method, URL, status and duration of every request. Headers and bodies, which
+// carry credentials and tokens, are never logged.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				logger.Printf("buildingx: %s %s failed after %s: %s", req.Method, req.URL.Redacted(), time.Since(start), err.Error())
+				return resp, err
+			}
+			logger.Printf("buildingx: %s %s returned %s in %s", req.Method, req.URL.Redacted(), resp.Status, time.Since(start))
+			return resp, err
+		})
+	}
+
+}
+
+// buildHTTPClient returns the HTTP client described by the configuration. A caller supplied http.Client is
+// copied rather than modified when a transport or middleware has to be installed.
+func buildHTTPClient(config Config) *http.Client {
+
+	if config.HTTPClient == nil && config.Transport == nil && len(config.Middleware) == 0 && config.Timeout == defaultTimeout {
+		return defaultHTTPClient
+	}
+
+	httpClient := &http.Client{Timeout: config.Timeout}
+	if config.HTTPClient != nil {
+		copied := *config.HTTPClient
+		httpClient = &copied
+	}
+	if config.Transport == nil && len(config.Middleware) == 0 {
+		return httpClient
+	}
+
+	transport := config.Transport
+	if transport == nil {
+		transport = httpClient.Transport
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(config.Middleware) - 1; i >= 0; i-- {
+		transport = config.Middleware[i](transport)
+	}
+	httpClient.Transport = transport
+
+	return httpClient
+
+}
@@ -0,0 +1,73 @@
+package buildingx
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":{"id":"%s"}}`, r.Header.Get("X-Tenant"))
+	}))
+
+	t.Run("middleware-runs-in-order", func(t *testing.T) {
+		order := make([]string, 0)
+		named := func(name string) Middleware {
+			return func(next http.RoundTripper) http.RoundTripper {
+				return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					order = append(order, name)
+					return next.RoundTrip(req)
+				})
+			}
+		}
+		client, err := NewClient(config,
+			WithMiddleware(named("outer"), named("inner")),
+			WithMiddleware(HeaderMiddleware(http.Header{"X-Tenant": []string{"tenant-a"}})))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		point, err := client.GetSinglePoint("point-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "tenant-a", point.ID)
+		// one token request and one API request
+		assert.Equal(t, []string{"outer", "inner", "outer", "inner"}, order)
+	})
+	t.Run("supplied-http-client-is-not-modified", func(t *testing.T) {
+		httpClient := &http.Client{}
+		var logged bytes.Buffer
+		client, err := NewClient(config, WithHTTPClient(httpClient), WithMiddleware(LoggingMiddleware(log.New(&logged, "", 0))))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		_, err = client.GetSinglePoint("point-1")
+		assert.Nil(t, err)
+		assert.Nil(t, httpClient.Transport)
+		assert.True(t, strings.Contains(logged.String(), "points/point-1"))
+		assert.False(t, strings.Contains(logged.String(), "Bearer"))
+	})
+	t.Run("custom-transport", func(t *testing.T) {
+		called := false
+		transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			called = true
+			return http.DefaultTransport.RoundTrip(req)
+		})
+		client, err := NewClient(config, WithTransport(transport))
+		if err != nil {
+			t.Fatal("error creating client: ", err.Error())
+		}
+
+		_, err = client.GetSinglePoint("point-1")
+		assert.Nil(t, err)
+		assert.True(t, called)
+	})
+
+}
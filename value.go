@@ -0,0 +1,352 @@
+package buildingx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidValue is matched by every ValueError
+var ErrInvalidValue = errors.New("invalid point value")
+
+// Data types reported by Point.DataType
+const (
+	DataTypeBoolean = "boolean"
+	DataTypeNumber  = "number"
+	DataTypeString  = "string"
+	// DataTypeEnum is reported for enumerated (multistate) points, whose value is the number of a state
+	DataTypeEnum = "enum"
+)
+
+// ValueKind is the kind of value held by a Value
+type ValueKind int
+
+const (
+	KindString ValueKind = iota
+	KindBool
+	KindNumber
+	KindEnum
+)
+
+func (k ValueKind) String() string {
+
+	switch k {
+	case KindBool:
+		return DataTypeBoolean
+	case KindNumber:
+		return DataTypeNumber
+	case KindEnum:
+		return DataTypeEnum
+	}
+	return DataTypeString
+
+}
+
+// ValueError reports a point value that does not match the data type, limits or states of the point. It
+// matches ErrInvalidValue.
+type ValueError struct {
+	// PointID is set when the value was validated for a point command
+	PointID  string
+	DataType string
+	Value    string
+	Reason   string
+}
+
+func (e *ValueError) Error() string {
+
+	if e.PointID != "" {
+		return fmt.Sprintf("invalid %s value %q for point %s: %s", e.DataType, e.Value, e.PointID, e.Reason)
+	}
+	return fmt.Sprintf("invalid %s value %q: %s", e.DataType, e.Value, e.Reason)
+
+}
+
+// Is makes errors.Is(err, ErrInvalidValue) true for every ValueError
+func (e *ValueError) Is(target error) bool {
+	return target == ErrInvalidValue
+}
+
+// Value is a point value decoded according to the data type of the point
+type Value struct {
+	kind ValueKind
+	b    bool
+	f    float64
+	n    int
+	s    string
+}
+
+// BoolValue returns a boolean value
+func BoolValue(b bool) Value {
+	return Value{kind: KindBool, b: b}
+}
+
+// NumberValue returns a numeric value
+func NumberValue(f float64) Value {
+	return Value{kind: KindNumber, f: f}
+}
+
+// EnumValue returns the value of an enumerated point, which is the number of its state
+func EnumValue(state int) Value {
+	return Value{kind: KindEnum, n: state}
+}
+
+// TextValue returns a string value
+func TextValue(s string) Value {
+	return Value{kind: KindString, s: s}
+}
+
+// ParseValue decodes a raw value as the given data type. Unknown data types are decoded as strings.
+func ParseValue(dataType, raw string) (Value, error) {
+
+	switch KindOf(dataType) {
+	case KindBool:
+		switch strings.ToLower(strings.TrimSpace(raw)) {
+		case "true", "1", "on", "active":
+			return BoolValue(true), nil
+		case "false", "0", "off", "inactive":
+			return BoolValue(false), nil
+		}
+		return Value{}, &ValueError{DataType: dataType, Value: raw, Reason: "not a boolean"}
+	case KindNumber:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return Value{}, &ValueError{DataType: dataType, Value: raw, Reason: "not a number"}
+		}
+		return NumberValue(f), nil
+	case KindEnum:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return Value{}, &ValueError{DataType: dataType, Value: raw, Reason: "not a state number"}
+		}
+		return EnumValue(n), nil
+	}
+	return TextValue(raw), nil
+
+}
+
+// KindOf returns the kind of value held by points of a data type. Data types are matched in any case and
+// with their aliases (ex: "multistate" holds enumerated values), and unknown ones hold strings.
+func KindOf(dataType string) ValueKind {
+
+	switch strings.ToLower(dataType) {
+	case DataTypeBoolean, "bool":
+		return KindBool
+	case DataTypeNumber, "float", "integer", "real", "analog":
+		return KindNumber
+	case DataTypeEnum, "enumeration", "multistate":
+		return KindEnum
+	}
+	return KindString
+
+}
+
+// Kind returns the kind of the value
+func (v Value) Kind() ValueKind {
+	return v.kind
+}
+
+// Bool returns a boolean value. It fails for any other kind of value.
+func (v Value) Bool() (bool, error) {
+
+	if v.kind != KindBool {
+		return false, v.kindError(KindBool)
+	}
+	return v.b, nil
+
+}
+
+// Float returns a numeric value. Enumerated values are returned as their state number. It fails for any
+// other kind of value.
+func (v Value) Float() (float64, error) {
+
+	switch v.kind {
+	case KindNumber:
+		return v.f, nil
+	case KindEnum:
+		return float64(v.n), nil
+	}
+	return 0, v.kindError(KindNumber)
+
+}
+
+// Enum returns the state number of an enumerated value. It fails for any other kind of value.
+func (v Value) Enum() (int, error) {
+
+	if v.kind != KindEnum {
+		return 0, v.kindError(KindEnum)
+	}
+	return v.n, nil
+
+}
+
+// String returns the value formatted as the API expects it
+func (v Value) String() string {
+
+	switch v.kind {
+	case KindBool:
+		return strconv.FormatBool(v.b)
+	case KindNumber:
+		return formatFloat(v.f)
+	case KindEnum:
+		return strconv.Itoa(v.n)
+	}
+	return v.s
+
+}
+
+func (v Value) kindError(want ValueKind) error {
+	return &ValueError{DataType: v.kind.String(), Value: v.String(), Reason: "not a " + want.String()}
+}
+
+// TypedValue decodes the value of the point according to its data type
+func (p Point) TypedValue() (Value, error) {
+	return ParseValue(p.DataType, p.StringValue)
+}
+
+// Bool returns the value of a boolean point
+func (p Point) Bool() (bool, error) {
+
+	v, err := p.TypedValue()
+	if err != nil {
+		return false, err
+	}
+	return v.Bool()
+
+}
+
+// Float returns the value of a numeric or enumerated point
+func (p Point) Float() (float64, error) {
+
+	v, err := p.TypedValue()
+	if err != nil {
+		return 0, err
+	}
+	return v.Float()
+
+}
+
+// StateText returns the label of a state of an enumerated point (numbered from 1), or the inactive and
+// active texts of a boolean point (states 0 and 1). It returns false when the point has no label for the state.
+func (p Point) StateText(state int) (string, bool) {
+
+	index := state - 1
+	if KindOf(p.DataType) == KindBool {
+		index = state
+	}
+	if index < 0 || index >= len(p.StateTexts) || p.StateTexts[index] == "" {
+		return "", false
+	}
+	return p.StateTexts[index], true
+
+}
+
+// FormattedValue returns the value of the point for display: the state text of boolean and enumerated
+// points, and the value followed by the units for numeric points. The raw value is returned when it cannot
+// be decoded.
+func (p Point) FormattedValue() string {
+
+	v, err := p.TypedValue()
+	if err != nil {
+		return p.StringValue
+	}
+
+	switch v.Kind() {
+	case KindBool:
+		state := 0
+		if v.b {
+			state = 1
+		}
+		if text, ok := p.StateText(state); ok {
+			return text
+		}
+	case KindEnum:
+		if text, ok := p.StateText(v.n); ok {
+			return text
+		}
+	case KindNumber:
+		if p.Units != "" {
+			return v.String() + " " + p.Units
+		}
+	}
+	return v.String()
+
+}
+
+// TypedValue decodes the value of a history record according to the data type of its point
+func (h PointHistory) TypedValue(dataType string) (Value, error) {
+	return ParseValue(dataType, h.Value)
+}
+
+// CommandBool sets the value of a writable boolean point
+func CommandBool(session *Session, point *Point, value bool, opts ...CommandOption) error {
+	return CommandBoolWithContext(context.Background(), session, point, value, opts...)
+}
+
+// CommandBoolWithContext is like CommandBool but uses the given context for the API call and any token refresh
+func CommandBoolWithContext(ctx context.Context, session *Session, point *Point, value bool, opts ...CommandOption) error {
+	return session.apiClient().commandValue(ctx, session, point, BoolValue(value), opts...)
+}
+
+// CommandBool sets the value of a writable boolean point
+func (c *Client) CommandBool(point *Point, value bool, opts ...CommandOption) error {
+	return c.CommandBoolWithContext(context.Background(), point, value, opts...)
+}
+
+// CommandBoolWithContext is like CommandBool but uses the given context for the API call and any token refresh
+func (c *Client) CommandBoolWithContext(ctx context.Context, point *Point, value bool, opts ...CommandOption) error {
+	return c.commandValue(ctx, c.session, point, BoolValue(value), opts...)
+}
+
+// CommandNumber sets the value of a writable numeric point
+func CommandNumber(session *Session, point *Point, value float64, opts ...CommandOption) error {
+	return CommandNumberWithContext(context.Background(), session, point, value, opts...)
+}
+
+// CommandNumberWithContext is like CommandNumber but uses the given context for the API call and any token refresh
+func CommandNumberWithContext(ctx context.Context, session *Session, point *Point, value float64, opts ...CommandOption) error {
+	return session.apiClient().commandValue(ctx, session, point, NumberValue(value), opts...)
+}
+
+// CommandNumber sets the value of a writable numeric point
+func (c *Client) CommandNumber(point *Point, value float64, opts ...CommandOption) error {
+	return c.CommandNumberWithContext(context.Background(), point, value, opts...)
+}
+
+// CommandNumberWithContext is like CommandNumber but uses the given context for the API call and any token refresh
+func (c *Client) CommandNumberWithContext(ctx context.Context, point *Point, value float64, opts ...CommandOption) error {
+	return c.commandValue(ctx, c.session, point, NumberValue(value), opts...)
+}
+
+// CommandEnum sets the state of a writable enumerated (multistate) point
+func CommandEnum(session *Session, point *Point, state int, opts ...CommandOption) error {
+	return CommandEnumWithContext(context.Background(), session, point, state, opts...)
+}
+
+// CommandEnumWithContext is like CommandEnum but uses the given context for the API call and any token refresh
+func CommandEnumWithContext(ctx context.Context, session *Session, point *Point, state int, opts ...CommandOption) error {
+	return session.apiClient().commandValue(ctx, session, point, EnumValue(state), opts...)
+}
+
+// CommandEnum sets the state of a writable enumerated (multistate) point
+func (c *Client) CommandEnum(point *Point, state int, opts ...CommandOption) error {
+	return c.CommandEnumWithContext(context.Background(), point, state, opts...)
+}
+
+// CommandEnumWithContext is like CommandEnum but uses the given context for the API call and any token refresh
+func (c *Client) CommandEnumWithContext(ctx context.Context, point *Point, state int, opts ...CommandOption) error {
+	return c.commandValue(ctx, c.session, point, EnumValue(state), opts...)
+}
+
+// commandValue checks that a typed value suits the data type of the point and commands it. A point with
+// no data type accepts any value, and the Force option skips the check.
+func (c *Client) commandValue(ctx context.Context, session *Session, point *Point, value Value, opts ...CommandOption) error {
+
+	if !newCommandOptions(opts).force && point.DataType != "" && KindOf(point.DataType) != value.Kind() {
+		return &ValueError{PointID: point.ID, DataType: point.DataType, Value: value.String(), Reason: "the point does not hold a " + value.Kind().String()}
+	}
+	return c.commandPointValue(ctx, session, point, value.String(), opts...)
+
+}
@@ -0,0 +1,121 @@
+package buildingx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseValue(t *testing.T) {
+
+	t.Run("boolean", func(t *testing.T) {
+		for raw, want := range map[string]bool{"true": true, "FALSE": false, "1": true, "0": false, "active": true, "off": false} {
+			v, err := ParseValue("boolean", raw)
+			if err != nil {
+				t.Fatal("error parsing value: ", err.Error())
+			}
+			b, err := v.Bool()
+			assert.Nil(t, err)
+			assert.Equal(t, want, b, raw)
+		}
+		_, err := ParseValue("boolean", "72.5")
+		assert.True(t, errors.Is(err, ErrInvalidValue))
+	})
+	t.Run("number", func(t *testing.T) {
+		v, err := ParseValue("number", " 72.5 ")
+		if err != nil {
+			t.Fatal("error parsing value: ", err.Error())
+		}
+		f, err := v.Float()
+		assert.Nil(t, err)
+		assert.Equal(t, 72.5, f)
+		assert.Equal(t, "72.5", v.String())
+
+		_, err = v.Bool()
+		assert.True(t, errors.Is(err, ErrInvalidValue))
+		_, err = ParseValue("number", "true")
+		assert.True(t, errors.Is(err, ErrInvalidValue))
+		_, err = ParseValue("number", "NaN")
+		assert.True(t, errors.Is(err, ErrInvalidValue))
+	})
+	t.Run("enum", func(t *testing.T) {
+		v, err := ParseValue("multistate", "3")
+		if err != nil {
+			t.Fatal("error parsing value: ", err.Error())
+		}
+		assert.Equal(t, KindEnum, v.Kind())
+		state, err := v.Enum()
+		assert.Nil(t, err)
+		assert.Equal(t, 3, state)
+		f, err := v.Float()
+		assert.Nil(t, err)
+		assert.Equal(t, 3.0, f)
+
+		_, err = ParseValue("enum", "occupied")
+		var valueErr *ValueError
+		assert.True(t, errors.As(err, &valueErr))
+		assert.Equal(t, "occupied", valueErr.Value)
+	})
+	t.Run("string-and-unknown-types", func(t *testing.T) {
+		v, err := ParseValue("string", "anything")
+		assert.Nil(t, err)
+		assert.Equal(t, "anything", v.String())
+		v, err = ParseValue("", "anything")
+		assert.Nil(t, err)
+		assert.Equal(t, KindString, v.Kind())
+	})
+	t.Run("data-type-aliases", func(t *testing.T) {
+		assert.Equal(t, KindBool, KindOf("Boolean"))
+		assert.Equal(t, KindNumber, KindOf("analog"))
+		assert.Equal(t, KindEnum, KindOf("multistate"))
+		assert.Equal(t, KindEnum, KindOf("enumeration"))
+		assert.Equal(t, KindString, KindOf("datetime"))
+	})
+	t.Run("point-accessors", func(t *testing.T) {
+		b, err := Point{DataType: "boolean", StringValue: "true"}.Bool()
+		assert.Nil(t, err)
+		assert.True(t, b)
+		f, err := Point{DataType: "number", StringValue: "55.2"}.Float()
+		assert.Nil(t, err)
+		assert.Equal(t, 55.2, f)
+		_, err = Point{DataType: "number", StringValue: ""}.Float()
+		assert.True(t, errors.Is(err, ErrInvalidValue))
+		v, err := PointHistory{Value: "0"}.TypedValue("boolean")
+		assert.Nil(t, err)
+		assert.Equal(t, BoolValue(false), v)
+	})
+
+}
+
+func TestTypedCommands(t *testing.T) {
+
+	values := make([]string, 0)
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		command := SBPointCommand{}
+		if err := json.NewDecoder(r.Body).Decode(&command); err != nil {
+			t.Error("error decoding command: ", err.Error())
+		}
+		values = append(values, command.Data.Attributes.PointValue.Value)
+	}))
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal("error creating client: ", err.Error())
+	}
+
+	t.Run("values-are-formatted", func(t *testing.T) {
+		assert.Nil(t, client.CommandBool(&Point{ID: "fan", DataType: "boolean", Writable: true}, true))
+		assert.Nil(t, client.CommandNumber(&Point{ID: "setpoint", DataType: "number", Writable: true}, 21.5))
+		assert.Nil(t, client.CommandEnum(&Point{ID: "mode", DataType: "multistate", Writable: true}, 2))
+		assert.Equal(t, []string{"true", "21.5", "2"}, values)
+	})
+	t.Run("data-type-mismatch-is-not-sent", func(t *testing.T) {
+		values = values[:0]
+		err := client.CommandBool(&Point{ID: "setpoint", DataType: "number", Writable: true}, true)
+		assert.True(t, errors.Is(err, ErrInvalidValue))
+		assert.Equal(t, 0, len(values))
+	})
+
+}
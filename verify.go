@@ -0,0 +1,171 @@
+package buildingx
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultVerifyTimeout  = 30 * time.Second
+	defaultVerifyFirst    = 500 * time.Millisecond
+	defaultVerifyMaxDelay = 5 * time.Second
+)
+
+// VerifyStatus is the outcome of CommandAndVerify
+type VerifyStatus int
+
+const (
+	// VerifyConfirmed means the point reported the commanded value after the command was sent
+	VerifyConfirmed VerifyStatus = iota + 1
+	// VerifyMismatched means the point reported new values until the timeout, none of which was the commanded one
+	VerifyMismatched
+	// VerifyTimedOut means the point did not report a new value before the timeout
+	VerifyTimedOut
+)
+
+func (s VerifyStatus) String() string {
+
+	switch s {
+	case VerifyConfirmed:
+		return "confirmed"
+	case VerifyMismatched:
+		return "mismatched"
+	case VerifyTimedOut:
+		return "timed out"
+	}
+	return "unknown"
+
+}
+
+// VerifyResult reports whether a command took effect
+type VerifyResult struct {
+	Status VerifyStatus
+	// Observed is the last state of the point read while verifying. Observed.StringValue is the value the
+	// point reported, which differs from the commanded value when the status is VerifyMismatched.
+	Observed Point
+	// Polls is the number of times the point was read
+	Polls int
+	// Elapsed is the time from the command until the outcome was known
+	Elapsed time.Duration
+}
+
+// VerifyTimeout sets how long CommandAndVerify waits for the point to report the commanded value. It defaults to 30s.
+func VerifyTimeout(timeout time.Duration) CommandOption {
+	return func(o *commandOptions) { o.verifyTimeout = timeout }
+}
+
+// VerifyPolling sets the delay before the first read of the point by CommandAndVerify, which doubles with
+// every read up to max. It defaults to 500ms doubling up to 5s.
+func VerifyPolling(first, max time.Duration) CommandOption {
+	return func(o *commandOptions) { o.verifyPolling = RetryPolicy{BaseDelay: first, MaxDelay: max} }
+}
+
+// CommandAndVerify commands a point and then reads it back, with backoff, until it reports the commanded
+// value with a timestamp later than point.Timestamp, or until the timeout passes. When point.Timestamp is
+// zero, the point is read before the command to learn it. Because the Building X API accepts writes that never
+// take effect, the result says whether the command was confirmed, whether the point only reported other values
+// or whether the verification timed out. An error is only returned when the command fails or the point cannot
+// be read.
+func CommandAndVerify(session *Session, point *Point, value string, opts ...CommandOption) (VerifyResult, error) {
+	return CommandAndVerifyWithContext(context.Background(), session, point, value, opts...)
+}
+
+// CommandAndVerifyWithContext is like CommandAndVerify but uses the given context for the API calls and any token refresh
+func CommandAndVerifyWithContext(ctx context.Context, session *Session, point *Point, value string, opts ...CommandOption) (VerifyResult, error) {
+	return session.apiClient().commandAndVerify(ctx, session, point, value, opts...)
+}
+
+// CommandAndVerify commands a point and reads it back until the command is confirmed or the timeout passes
+func (c *Client) CommandAndVerify(point *Point, value string, opts ...CommandOption) (VerifyResult, error) {
+	return c.CommandAndVerifyWithContext(context.Background(), point, value, opts...)
+}
+
+// CommandAndVerifyWithContext is like CommandAndVerify but uses the given context for the API calls and any token refresh
+func (c *Client) CommandAndVerifyWithContext(ctx context.Context, point *Point, value string, opts ...CommandOption) (VerifyResult, error) {
+	return c.commandAndVerify(ctx, c.session, point, value, opts...)
+}
+
+func (c *Client) commandAndVerify(ctx context.Context, session *Session, point *Point, value string, opts ...CommandOption) (VerifyResult, error) {
+
+	result := VerifyResult{}
+	options := newCommandOptions(opts)
+
+	err := c.operation(ctx, "CommandAndVerify", session, func(ctx context.Context) error {
+
+		// a new value is one reported after the last known state of the point
+		since := point.Timestamp
+		if since.IsZero() {
+			current, err := c.getSinglePoint(ctx, session, point.ID)
+			if err != nil {
+				return err
+			}
+			since = current.Timestamp
+		}
+
+		if err := c.commandPointValue(ctx, session, point, value, opts...); err != nil {
+			return err
+		}
+		start := time.Now()
+		deadline := start.Add(options.verifyTimeout)
+		reported := false
+
+		for poll := 1; ; poll++ {
+
+			// wait before reading, but never past the deadline
+			wait := options.verifyPolling.delay(poll, nil)
+			if remaining := time.Until(deadline); wait > remaining {
+				wait = remaining
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+
+			observed, err := c.getSinglePoint(ctx, session, point.ID)
+			result.Polls = poll
+			result.Elapsed = time.Since(start)
+			if err != nil && (ctx.Err() != nil || !IsRetryable(err)) {
+				return err
+			}
+			if err == nil {
+				result.Observed = observed
+				if observed.Timestamp.After(since) {
+					if sameValue(point.DataType, observed.StringValue, value) {
+						result.Status = VerifyConfirmed
+						return nil
+					}
+					// another value may be reported before the command takes effect, so keep polling
+					reported = true
+				}
+			}
+
+			if !time.Now().Before(deadline) {
+				result.Status = VerifyTimedOut
+				if reported {
+					result.Status = VerifyMismatched
+				}
+				return nil
+			}
+
+		}
+
+	})
+
+	return result, err
+
+}
+
+// sameValue compares two raw values of a data type, so that "72" and "72.0" or "1" and "true" are equal
+func sameValue(dataType, a, b string) bool {
+
+	va, errA := ParseValue(dataType, a)
+	vb, errB := ParseValue(dataType, b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return va == vb
+
+}
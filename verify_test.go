@@ -0,0 +1,79 @@
+package buildingx_test
+
+import (
+	"testing"
+	"time"
+
+	buildingx "github.com/cloudlinesolutions/buildingx-operations-api"
+	"github.com/cloudlinesolutions/buildingx-operations-api/buildingxtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandAndVerify(t *testing.T) {
+
+	polling := buildingx.VerifyPolling(time.Millisecond, 10*time.Millisecond)
+
+	// override holds the setpoint at 70 with a command at priority 8, above the commands being verified
+	override := func(t *testing.T, client *buildingx.Client) *buildingx.Point {
+		point := sitePoint(t, client, "point-3")
+		if err := client.CommandPointValue(point, "70", buildingx.Priority(8)); err != nil {
+			t.Fatal("error commanding point: ", err.Error())
+		}
+		return sitePoint(t, client, "point-3")
+	}
+
+	t.Run("confirmed", func(t *testing.T) {
+		_, client := newSite(t)
+		result, err := client.CommandAndVerify(sitePoint(t, client, "point-3"), "74.0", polling)
+		assert.Nil(t, err)
+		assert.Equal(t, buildingx.VerifyConfirmed, result.Status)
+		assert.Equal(t, "74.0", result.Observed.StringValue)
+		assert.Equal(t, 1, result.Polls)
+	})
+	t.Run("keeps-polling-until-confirmed", func(t *testing.T) {
+		_, client := newSite(t)
+		point := override(t, client)
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			client.ReleasePointCommand(point, buildingx.Priority(8))
+		}()
+		result, err := client.CommandAndVerify(point, "74", polling, buildingx.VerifyTimeout(5*time.Second))
+		assert.Nil(t, err)
+		assert.Equal(t, buildingx.VerifyConfirmed, result.Status)
+		assert.Greater(t, result.Polls, 1)
+	})
+	t.Run("mismatched", func(t *testing.T) {
+		_, client := newSite(t)
+		result, err := client.CommandAndVerify(override(t, client), "74", polling, buildingx.VerifyTimeout(50*time.Millisecond))
+		assert.Nil(t, err)
+		assert.Equal(t, buildingx.VerifyMismatched, result.Status)
+		assert.Equal(t, "70", result.Observed.StringValue)
+		assert.True(t, result.Elapsed >= 50*time.Millisecond)
+	})
+	t.Run("timed-out", func(t *testing.T) {
+		server, client := newSite(t)
+		point := sitePoint(t, client, "point-3")
+		server.InjectFault(buildingxtest.Fault{Method: "GET", Path: "points/point-3", Status: 503})
+		result, err := client.CommandAndVerify(point, "74", polling, buildingx.VerifyTimeout(50*time.Millisecond))
+		assert.Nil(t, err)
+		assert.Equal(t, buildingx.VerifyTimedOut, result.Status)
+		assert.Greater(t, result.Polls, 1)
+		assert.True(t, result.Elapsed >= 50*time.Millisecond)
+	})
+	t.Run("unknown-timestamp", func(t *testing.T) {
+		// the command is accepted but never takes effect, so the point keeps its old value and timestamp
+		server, client := newSite(t)
+		server.InjectFault(buildingxtest.Fault{Method: "PATCH", Path: "points/point-3", Status: 204})
+		point := &buildingx.Point{ID: "point-3", DataType: buildingx.DataTypeNumber, Writable: true}
+		result, err := client.CommandAndVerify(point, "74", polling, buildingx.VerifyTimeout(50*time.Millisecond))
+		assert.Nil(t, err)
+		assert.Equal(t, buildingx.VerifyTimedOut, result.Status)
+		assert.Equal(t, "72", result.Observed.StringValue)
+	})
+	t.Run("invalid-command-is-an-error", func(t *testing.T) {
+		_, client := newSite(t)
+		_, err := client.CommandAndVerify(sitePoint(t, client, "point-3"), "warm", polling)
+		assert.NotNil(t, err)
+	})
+
+}